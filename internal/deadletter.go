@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// deadLetterSink writes the skipped documents of every zip of a run to cfg.DeadLetter
+type deadLetterSink struct {
+	cfg types.DeadLetter
+	mu  sync.Mutex // Serializes writes to cfg.Writer
+}
+
+// newDeadLetterSink returns nil when no sink is configured
+func newDeadLetterSink(cfg types.DeadLetter) *deadLetterSink {
+	if !cfg.Enabled() {
+		return nil
+	}
+	return &deadLetterSink{cfg: cfg}
+}
+
+// write delivers the document skipped with err, which must carry its RawSplitDoc
+func (s *deadLetterSink) write(err *types.USPTGoError) error {
+	record := types.NewDeadLetterRecord(err)
+
+	if s.cfg.Dir != "" {
+		if err := writeDeadLetterFiles(s.cfg.Dir, record); err != nil {
+			return err
+		}
+	}
+
+	if s.cfg.Writer != nil {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if _, err := s.cfg.Writer.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeDeadLetterFiles writes the raw document as dir/<ZipName>-<hash>/<IndexName>, beside its record as <IndexName>.json.
+// The hash of the ZipPath keeps apart inputs of the same name from different directories, and IndexName keeps the path of its entry within the input.
+func writeDeadLetterFiles(dir string, record types.DeadLetterRecord) error {
+	origin := record.Metadata.OriginZip
+	sum := sha256.Sum256([]byte(origin.ZipPath))
+	zipDir := filepath.Join(dir, fmt.Sprintf("%s-%x", filepath.Base(origin.ZipName), sum[:4]))
+
+	// Cleaned as an absolute path, so that no ".." of an entry name can leave zipDir
+	rawPath := filepath.Join(zipDir, filepath.FromSlash(path.Clean("/"+origin.IndexName)))
+	if err := os.MkdirAll(filepath.Dir(rawPath), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(rawPath, record.RawSplitDoc, 0o644); err != nil {
+		return err
+	}
+
+	record.RawSplitDoc = nil
+	sidecar, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(rawPath+".json", sidecar, 0o644)
+}
+
+// readDeadLetters reads the records written to a DeadLetter Dir, or to a file by a DeadLetter Writer
+func readDeadLetters(path string) ([]types.DeadLetterRecord, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return readDeadLetterLines(path)
+	}
+
+	var records []types.DeadLetterRecord
+	err = filepath.WalkDir(path, func(sidecarPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() || !strings.HasSuffix(sidecarPath, ".json") {
+			return nil
+		}
+		sidecar, err := os.ReadFile(sidecarPath)
+		if err != nil {
+			return err
+		}
+		var record types.DeadLetterRecord
+		if err := json.Unmarshal(sidecar, &record); err != nil {
+			return fmt.Errorf("reading dead letter %s: %w", sidecarPath, err)
+		}
+		// The raw document sits beside its record, and may have been corrected by hand since
+		if record.RawSplitDoc, err = os.ReadFile(strings.TrimSuffix(sidecarPath, ".json")); err != nil {
+			return err
+		}
+		records = append(records, record)
+		return nil
+	})
+	return records, err
+}
+
+func readDeadLetterLines(path string) ([]types.DeadLetterRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []types.DeadLetterRecord
+	decoder := json.NewDecoder(bufio.NewReader(f))
+	for decoder.More() {
+		var record types.DeadLetterRecord
+		if err := decoder.Decode(&record); err != nil {
+			return nil, fmt.Errorf("reading dead letters from %s: %w", path, err)
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// deadLetterInputs groups dead letters by their origin, each group becoming an input of its own, in the order the origins are first seen.
+// Inputs sharing a name in different directories are kept apart by their ZipPath.
+func deadLetterInputs(path string) ([]input, error) {
+	records, err := readDeadLetters(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("no dead letters found in " + path)
+	}
+
+	type origin struct {
+		zipPath, zipName, zipEntryExt, documentType string
+	}
+	var (
+		resolved []input
+		indexes  = make(map[origin]int)
+	)
+	for _, record := range records {
+		key := origin{record.Metadata.OriginZip.ZipPath, record.Metadata.OriginZip.ZipName, record.Metadata.OriginZip.ZipEntryExt, record.Metadata.DocumentType}
+		i, ok := indexes[key]
+		if !ok {
+			i = len(resolved)
+			indexes[key] = i
+			resolved = append(resolved, input{})
+		}
+		resolved[i].letters = append(resolved[i].letters, record)
+	}
+
+	// A directory is walked in name order, which puts "ipg-10.xml" before "ipg-2.xml"
+	for _, in := range resolved {
+		sort.SliceStable(in.letters, func(i, j int) bool {
+			return in.letters[i].Metadata.OriginZip.IndexInZip < in.letters[j].Metadata.OriginZip.IndexInZip
+		})
+	}
+	return resolved, nil
+}
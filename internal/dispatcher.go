@@ -1,59 +1,333 @@
 package internal
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/diverged/uspt-go/internal/pipeline"
+	"github.com/diverged/uspt-go/internal/stats"
 	"github.com/diverged/uspt-go/internal/utils"
 	"github.com/diverged/uspt-go/types"
 )
 
-func Dispatcher(cfg *types.USPTGoConfig) (docChanOut <-chan *types.USPTGoDoc, errChanOut <-chan error, err error) {
+// Dispatcher resolves the inputs of cfg and starts their pipelines, returning the merged output channels and the tracker of the run's statistics
+func Dispatcher(ctx context.Context, cfg *types.USPTGoConfig) (docChanOut <-chan *types.USPTGoDoc, errChanOut <-chan error, runStats *stats.Run, err error) {
+
+	cfg.Logger.Debug("Dispatcher called", "path", cfg.InputPath, "paths", len(cfg.InputPaths))
+
+	inputs, err := resolveInputs(cfg)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return dispatch(ctx, cfg, inputs)
+}
+
+// ReprocessDispatcher runs the dead letters read from path through the pipelines which skipped them, in place of the inputs of cfg
+func ReprocessDispatcher(ctx context.Context, cfg *types.USPTGoConfig, path string) (docChanOut <-chan *types.USPTGoDoc, errChanOut <-chan error, runStats *stats.Run, err error) {
+
+	cfg.Logger.Debug("ReprocessDispatcher called", "path", path)
+
+	inputs, err := deadLetterInputs(path)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return dispatch(ctx, cfg, inputs)
+}
+
+// dispatch starts the pipelines of inputs, up to MaxConcurrentZips at once, merging their output into the returned channels
+func dispatch(ctx context.Context, cfg *types.USPTGoConfig, inputs []input) (docChanOut <-chan *types.USPTGoDoc, errChanOut <-chan error, runStats *stats.Run, err error) {
 
 	log := cfg.Logger
 
-	log.Debug("Dispatcher called", "path", cfg.InputPath)
+	outputBufferSize := cfg.Tuning.OutputBufferSize
+	if outputBufferSize <= 0 {
+		outputBufferSize = 1000
+	}
+	docChan := make(chan *types.USPTGoDoc, outputBufferSize)
+	errChan := make(chan error, outputBufferSize)
+	sink := newDeadLetterSink(cfg.DeadLetter)
 
-	docChan := make(chan *types.USPTGoDoc, 1000)
-	errChan := make(chan error, 1000)
+	names := make([]string, len(inputs))
+	for i, in := range inputs {
+		names[i] = in.name()
+	}
+	runStats = stats.NewRun(names)
 
-	zipFilePath := cfg.InputPath
+	// A lone path which is not a bulk file is rejected up front, as there is nothing else to process
+	if len(inputs) == 1 && inputs[0].reader == nil && inputs[0].letters == nil && !isBulkFile(inputs[0].path) {
+		err = errors.New("file is not a zip, tar or xml bulk file")
+		notBulkErr := &types.USPTGoError{
+			Err:     err,
+			Skipped: true,
+			Kind:    types.ErrNotZip,
+			Name:    filepath.Base(inputs[0].path),
+			Type:    "zip",
+			Whence:  "file is not a zip, tar or xml bulk file",
+			ZipInfo: inputs[0].originZip(),
+		}
+		errChan <- notBulkErr
+		close(errChan)
+		finishRun(cfg, runStats, notBulkErr)
+		return docChan, errChan, runStats, err
+	}
 
-	if filepath.Ext(zipFilePath) != ".zip" {
-		err = errors.New("file is not a zip archive")
-		errChan <- &types.USPTGoError{
+	// A context cancelled before the run begins produces no documents, only its error
+	if err = ctx.Err(); err != nil {
+		cancelledErr := &types.USPTGoError{
 			Err:     err,
 			Skipped: true,
-			Name:    filepath.Base(zipFilePath),
+			Kind:    types.ErrCancelled,
+			Name:    filepath.Base(inputs[0].name()),
 			Type:    "zip",
-			Whence:  "file is not a zip archive",
+			Whence:  "starting to process the zip file",
+			ZipInfo: inputs[0].originZip(),
 		}
+		errChan <- cancelledErr
 		close(errChan)
-		return docChan, errChan, err
+		close(docChan)
+		finishRun(cfg, runStats, cancelledErr)
+		return docChan, errChan, runStats, err
+	}
+
+	maxConcurrentZips := cfg.Tuning.MaxConcurrentZips
+	if maxConcurrentZips <= 0 {
+		maxConcurrentZips = 1
+	}
+
+	go func() {
+		defer close(docChan)
+		defer close(errChan)
+
+		stopProgress := reportProgress(cfg, runStats)
+
+		var (
+			wg        sync.WaitGroup
+			semaphore = make(chan struct{}, maxConcurrentZips)
+			started   int
+		)
+
+	zips:
+		for i, in := range inputs {
+			select {
+			case semaphore <- struct{}{}:
+			case <-ctx.Done():
+				break zips
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			started++
+
+			wg.Add(1)
+			go func(index int, in input) {
+				defer wg.Done()
+				defer func() { <-semaphore }()
+				mergeZip(ctx, cfg, index, len(inputs), in, runStats.Zip(index), sink, docChan, errChan)
+			}(i, in)
+		}
+		wg.Wait()
+
+		if err := ctx.Err(); err != nil {
+			log.Info("Dispatcher cancelled", "zips started", started, "zips total", len(inputs), "error", err)
+			cancelledErr := &types.USPTGoError{
+				Err:     err,
+				Skipped: true,
+				Kind:    types.ErrCancelled,
+				Name:    filepath.Base(inputs[0].name()),
+				Type:    "zip",
+				Whence:  "processing the zip files",
+				ZipInfo: inputs[0].originZip(),
+			}
+			select {
+			case errChan <- cancelledErr:
+				runStats.Error(cancelledErr)
+			default:
+				// errChan is full and no longer being read, so there is no one left to tell
+			}
+		}
+
+		runStats.Finish()
+		stopProgress()
+	}()
+
+	// Type-cast channels to receive-only for the return values
+	docChanOut = docChan
+	errChanOut = errChan
+
+	return docChanOut, errChanOut, runStats, nil
+}
+
+// reportProgress calls cfg.Progress every cfg.ProgressInterval until the returned stop is called, which makes the final call
+func reportProgress(cfg *types.USPTGoConfig, runStats *stats.Run) (stop func()) {
+	if cfg.Progress == nil {
+		return func() {}
+	}
+	interval := cfg.ProgressInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				cfg.Progress(runStats.Snapshot())
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+		cfg.Progress(runStats.Snapshot())
+	}
+}
+
+// finishRun closes the statistics of a run which ended before any zip started, on the error it reported
+func finishRun(cfg *types.USPTGoConfig, runStats *stats.Run, err error) {
+	runStats.Error(err)
+	runStats.Finish()
+	if cfg.Progress != nil {
+		cfg.Progress(runStats.Snapshot())
+	}
+}
+
+// mergeZip runs the pipeline of a single zip, relaying its documents and errors to the shared docChan and errChan, and reports its start and finish to cfg.OnZipEvent.
+// Once ctx is cancelled the zip's remaining output is drained and dropped, leaving the Dispatcher to report the cancellation once.
+func mergeZip(ctx context.Context, cfg *types.USPTGoConfig, index, total int, in input, zipStats *stats.Zip, sink *deadLetterSink, docChan chan<- *types.USPTGoDoc, errChan chan<- error) {
+
+	zipStats.Start()
+	event := types.ZipEvent{ZipPath: in.name(), Index: index, Total: total}
+	if cfg.OnZipEvent != nil {
+		event.Kind = types.ZipStarted
+		cfg.OnZipEvent(event)
+	}
+
+	zipDocChan, zipErrChan := dispatchZip(ctx, cfg, in, zipStats)
+
+	for zipDocChan != nil || zipErrChan != nil {
+		select {
+		case doc, ok := <-zipDocChan:
+			if !ok {
+				zipDocChan = nil
+				continue
+			}
+			if ctx.Err() == nil && utils.SendDoc(ctx, docChan, doc) {
+				zipStats.Documents.Add(1)
+				if len(doc.Diagnostics) > 0 {
+					zipStats.Partial.Add(1)
+				}
+			}
+		case err, ok := <-zipErrChan:
+			if !ok {
+				zipErrChan = nil
+				continue
+			}
+			deliverDeadLetter(cfg, sink, err)
+			if ctx.Err() == nil && utils.SendErr(ctx, errChan, err) {
+				zipStats.Error(err)
+			}
+		}
+	}
+
+	zipStats.Finish()
+	if cfg.OnZipEvent != nil {
+		event.Kind = types.ZipFinished
+		event.Documents = int(zipStats.Documents.Load())
+		event.Errors = int(zipStats.Errors.Load())
+		event.Prefiltered = int(zipStats.Prefiltered.Load())
+		event.Postfiltered = int(zipStats.Postfiltered.Load())
+		event.Elapsed = zipStats.Elapsed()
+		cfg.OnZipEvent(event)
+	}
+}
+
+// deliverDeadLetter writes the document skipped with err to the sink, if any, then drops its raw bytes from err unless cfg.ReturnRawSplitDoc is set
+func deliverDeadLetter(cfg *types.USPTGoConfig, sink *deadLetterSink, err error) {
+	usptgoErr, ok := err.(*types.USPTGoError)
+	if !ok || usptgoErr.RawSplitDoc == nil {
+		return
+	}
+	if sink != nil {
+		if writeErr := sink.write(usptgoErr); writeErr != nil {
+			cfg.Logger.Error("Unable to write dead letter", "DocName", usptgoErr.ZipInfo.IndexName, "error", writeErr)
+		}
+	}
+	if !cfg.ReturnRawSplitDoc {
+		usptgoErr.RawSplitDoc = nil
 	}
+}
+
+// dispatchZip opens and inspects a single input and starts the pipeline matching its format, returning the input's own output channels, which are closed once it is done
+func dispatchZip(ctx context.Context, cfg *types.USPTGoConfig, in input, zipStats *stats.Zip) (<-chan *types.USPTGoDoc, <-chan error) {
 
-	var wg sync.WaitGroup
+	log := cfg.Logger
+
+	bufferSize := cfg.Tuning.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = 100
+	}
+	docChan := make(chan *types.USPTGoDoc, bufferSize)
+	errChan := make(chan error, bufferSize)
+
+	zipFilePath := in.name()
 
-	wg.Add(1)
 	go func() {
-		defer wg.Done()
-		// Inspect the zip to determine the file format and schema version
-		zipProfile, err := utils.InspectZip(zipFilePath)
+		// Dead letters are already split, and carry the profile of the zip they came from
+		if in.letters != nil {
+			zipProfile := &types.USPTGoMetadata{DocumentType: in.letters[0].Metadata.DocumentType, OriginZip: in.originZip()}
+			pipeline.ReprocessPipeline(ctx, in.letters, zipProfile, cfg, zipStats, docChan, errChan)
+			return
+		}
+
+		// Open the zip, tar or xml file, whether on disk or supplied as a reader
+		source, err := in.open()
 		if err != nil {
-			log.Error("zip file skipped due to error encountered while inspecting", "path", zipFilePath)
-			err = errors.New("zip file skipped due to error encountered while inspecting")
+			log.Error("input skipped due to error encountered while opening", "path", zipFilePath, "error", err)
 			errChan <- &types.USPTGoError{
 				Err:     err,
+				Kind:    types.ErrOpen,
+				Skipped: true,
+				Name:    filepath.Base(zipFilePath),
+				Type:    "zip",
+				Whence:  "opening the input",
+				ZipInfo: in.originZip(),
+			}
+			close(errChan)
+			close(docChan)
+			return
+		}
+
+		zipStats.SetSource(source.Size, source.BytesRead)
+
+		// Inspect the input to determine the file format and schema version
+		zipProfile, err := utils.InspectSource(source)
+		if err != nil {
+			source.Close()
+			log.Error("zip file skipped due to error encountered while inspecting", "path", zipFilePath, "error", err)
+			errChan <- &types.USPTGoError{
+				Err:     fmt.Errorf("zip file skipped due to error encountered while inspecting: %w", err),
+				Kind:    types.ErrInspect,
 				Skipped: true,
 				Name:    zipFilePath,
 				Type:    "zip",
 				Whence:  "while attempting to inspect the zip file",
+				ZipInfo: in.originZip(),
 			}
 			close(errChan)
 			close(docChan)
-			// return nil, nil, errors.New("error encountered while inspecting zip file")
+			return
 		}
 		log.Debug("zip inspected successfully, proceeding with parsing logic", "path", zipFilePath)
 
@@ -62,30 +336,32 @@ func Dispatcher(cfg *types.USPTGoConfig) (docChanOut <-chan *types.USPTGoDoc, er
 		case ".xml":
 			// Process XML files
 			log.Debug("matched .xml zip entry extension", "path", zipProfile.OriginZip.ZipName)
-			pipeline.XMLPipeline(zipProfile, cfg, docChan, errChan)
+			pipeline.XMLPipeline(ctx, source, zipProfile, cfg, zipStats, docChan, errChan)
 
-		case ".aps":
+		case ".txt":
 			// Process APS files
-			log.Debug("matched .aps zip entry extension, handling for which is not yet implemented.", "path", zipProfile.OriginZip.ZipName)
+			log.Debug("matched .txt zip entry extension", "path", zipProfile.OriginZip.ZipName)
+			pipeline.APSPipeline(ctx, source, zipProfile, cfg, zipStats, docChan, errChan)
 
 		default:
+			source.Close()
 			log.Error("Unknown file extension inside zip file", "path", zipFilePath, "extension", zipProfile.OriginZip.ZipEntryExt)
 			errChan <- &types.USPTGoError{
 				Err:     errors.New("unknown file extension mistakenly encountered within zip file"),
+				Kind:    types.ErrInspect,
 				Name:    zipProfile.OriginZip.ZipName,
 				Type:    zipProfile.OriginZip.ZipEntryExt,
 				Whence:  "while attempting to profile the zip",
 				Skipped: true,
+				ZipInfo: zipProfile.OriginZip,
 			}
+			close(errChan)
+			close(docChan)
 			// Return exits the go routine on the unrecognized file extension, effectively skipping that zip file
 			// return nil, nil, errors.New("unrecognized file extension found within zip")
 
 		}
 	}()
-	wg.Wait()
-	// Type-cast channels to receive-only for the return values
-	docChanOut = docChan
-	errChanOut = errChan
 
-	return docChanOut, errChanOut, nil
+	return docChan, errChan
 }
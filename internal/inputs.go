@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/diverged/uspt-go/internal/utils"
+	"github.com/diverged/uspt-go/types"
+)
+
+// input is a single bulk file to process, given either by path or as a types.Input, or the dead letters of a single bulk file
+type input struct {
+	path    string
+	reader  *types.Input
+	letters []types.DeadLetterRecord
+}
+
+// name is the path of the input, the Name of a reader input, or the ZipPath the dead letters came from, falling back to its ZipName
+func (in input) name() string {
+	switch {
+	case in.reader != nil:
+		return in.reader.Name
+	case in.letters != nil:
+		if origin := in.letters[0].Metadata.OriginZip; origin.ZipPath != "" {
+			return origin.ZipPath
+		}
+		return in.letters[0].Metadata.OriginZip.ZipName
+	}
+	return in.path
+}
+
+// originZip identifies the input in the errors reported before it is inspected
+func (in input) originZip() types.OriginZip {
+	if in.letters != nil {
+		origin := in.letters[0].Metadata.OriginZip
+		return types.OriginZip{ZipPath: origin.ZipPath, ZipName: origin.ZipName, ZipEntryExt: origin.ZipEntryExt, Schema: origin.Schema, SchemaVersion: origin.SchemaVersion}
+	}
+	return types.OriginZip{ZipPath: in.path, ZipName: filepath.Base(in.name())}
+}
+
+func (in input) open() (*utils.BulkSource, error) {
+	if in.reader != nil {
+		return utils.OpenInput(*in.reader)
+	}
+	return utils.OpenPath(in.path)
+}
+
+// resolveInputs expands cfg.InputPath and cfg.InputPaths into the list of bulk files to process, followed by the reader inputs of cfg.Inputs.
+// Directories contribute every bulk file (zip, tar or xml) they directly contain and globs every bulk file they match, each in name order.  Explicit file paths are kept as given, so that an unsupported file is reported rather than silently ignored.
+func resolveInputs(cfg *types.USPTGoConfig) ([]input, error) {
+
+	inputs := cfg.InputPaths
+	if cfg.InputPath != "" {
+		inputs = append([]string{cfg.InputPath}, inputs...)
+	}
+	if len(inputs) == 0 && len(cfg.Inputs) == 0 {
+		return nil, fmt.Errorf("no input path provided")
+	}
+
+	var (
+		resolved []input
+		seen     = make(map[string]bool)
+	)
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			resolved = append(resolved, input{path: path})
+		}
+	}
+
+	for _, inputPath := range inputs {
+		switch {
+		case strings.ContainsAny(inputPath, "*?["):
+			matches, err := filepath.Glob(inputPath)
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob %q: %w", inputPath, err)
+			}
+			sort.Strings(matches)
+			for _, match := range matches {
+				if isBulkFile(match) {
+					add(match)
+				}
+			}
+
+		default:
+			info, err := os.Stat(inputPath)
+			if err != nil || !info.IsDir() {
+				add(inputPath)
+				continue
+			}
+			entries, err := os.ReadDir(inputPath)
+			if err != nil {
+				return nil, fmt.Errorf("reading inputPath directory %q: %w", inputPath, err)
+			}
+			// ReadDir returns the entries sorted by filename
+			for _, entry := range entries {
+				path := filepath.Join(inputPath, entry.Name())
+				if !entry.IsDir() && isBulkFile(path) {
+					add(path)
+				}
+			}
+		}
+	}
+
+	if len(resolved) == 0 && len(inputs) > 0 {
+		return nil, fmt.Errorf("no bulk files found in %s", strings.Join(inputs, ", "))
+	}
+
+	for i := range cfg.Inputs {
+		resolved = append(resolved, input{reader: &cfg.Inputs[i]})
+	}
+	return resolved, nil
+}
+
+func isBulkFile(path string) bool {
+	return types.InputFormatOf(path) != ""
+}
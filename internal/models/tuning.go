@@ -1,5 +0,0 @@
-package models
-
-type Tuning struct {
-	MaxConcurrentZips int
-}
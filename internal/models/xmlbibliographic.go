@@ -0,0 +1,218 @@
+package models
+
+import "encoding/xml"
+
+// XMLBibliographicParties maps the party elements of <us-bibliographic-data-grant> and <us-bibliographic-data-application>.
+// The v40-v42 schemas nest applicants within <parties><applicants>, while v43 onward uses <us-parties><us-applicants> alongside a separate <inventors> list.
+type XMLBibliographicParties struct {
+	UsParties XMLParties    `xml:"us-parties"`
+	Parties   XMLParties    `xml:"parties"`
+	Assignees []XMLAssignee `xml:"assignees>assignee"`
+}
+
+type XMLParties struct {
+	UsApplicants []XMLApplicant `xml:"us-applicants>us-applicant"`
+	Applicants   []XMLApplicant `xml:"applicants>applicant"`
+	Inventors    []XMLInventor  `xml:"inventors>inventor"`
+	Agents       []XMLAgent     `xml:"agents>agent"`
+}
+
+type XMLApplicant struct {
+	Sequence    string         `xml:"sequence,attr"`
+	AppType     string         `xml:"app-type,attr"`
+	Category    string         `xml:"applicant-authority-category,attr"` // v45+ applications replace app-type with this attribute
+	Addressbook XMLAddressbook `xml:"addressbook"`
+	Nationality string         `xml:"nationality>country"`
+	Residence   string         `xml:"residence>country"`
+}
+
+type XMLInventor struct {
+	Sequence    string         `xml:"sequence,attr"`
+	Addressbook XMLAddressbook `xml:"addressbook"`
+}
+
+type XMLAgent struct {
+	Sequence    string         `xml:"sequence,attr"`
+	RepType     string         `xml:"rep-type,attr"`
+	Addressbook XMLAddressbook `xml:"addressbook"`
+}
+
+type XMLAssignee struct {
+	Addressbook XMLAddressbook `xml:"addressbook"`
+	// A few v40-v41 documents place the assignee name directly under <assignee>
+	Orgname string `xml:"orgname"`
+	Role    string `xml:"role"`
+}
+
+type XMLAddressbook struct {
+	LastName   string `xml:"last-name"`
+	FirstName  string `xml:"first-name"`
+	MiddleName string `xml:"middle-name"`
+	Suffix     string `xml:"suffix"`
+	Orgname    string `xml:"orgname"`
+	Role       string `xml:"role"`
+	Street     string `xml:"address>street"`
+	Address1   string `xml:"address>address-1"`
+	City       string `xml:"address>city"`
+	State      string `xml:"address>state"`
+	Postcode   string `xml:"address>postcode"`
+	Country    string `xml:"address>country"`
+}
+
+// XMLBibliographicClassifications maps the IPC, CPC and Locarno classification elements of the v40-v47 schemas
+type XMLBibliographicClassifications struct {
+	IPCR       []XMLClassification `xml:"classifications-ipcr>classification-ipcr"`
+	MainCPC    []XMLClassification `xml:"classifications-cpc>main-cpc>classification-cpc"`
+	FurtherCPC []XMLClassification `xml:"classifications-cpc>further-cpc>classification-cpc"`
+	Locarno    *struct {
+		Edition            string `xml:"edition"`
+		MainClassification string `xml:"main-classification"`
+	} `xml:"classification-locarno"`
+}
+
+// XMLClassification maps both <classification-ipcr> and <classification-cpc>, which differ only in their version indicator and scheme origination code
+type XMLClassification struct {
+	IPCVersionDate        string `xml:"ipc-version-indicator>date"`
+	CPCVersionDate        string `xml:"cpc-version-indicator>date"`
+	Level                 string `xml:"classification-level"`
+	Section               string `xml:"section"`
+	Class                 string `xml:"class"`
+	Subclass              string `xml:"subclass"`
+	MainGroup             string `xml:"main-group"`
+	Subgroup              string `xml:"subgroup"`
+	SymbolPosition        string `xml:"symbol-position"`
+	Value                 string `xml:"classification-value"`
+	ActionDate            string `xml:"action-date>date"`
+	GeneratingOffice      string `xml:"generating-office>country"`
+	Status                string `xml:"classification-status"`
+	DataSource            string `xml:"classification-data-source"`
+	SchemeOriginationCode string `xml:"scheme-origination-code"`
+}
+
+// XMLBibliographicCitations maps the cited references, which are <references-cited> before v4.2 and <us-references-cited> from v4.2
+type XMLBibliographicCitations struct {
+	ReferencesCited   []XMLCitation `xml:"references-cited>citation"`
+	UsReferencesCited []XMLCitation `xml:"us-references-cited>us-citation"`
+}
+
+// XMLCitation holds either a patent (patcit) or non-patent (nplcit) reference, with the party that cited it
+type XMLCitation struct {
+	PatCit   *XMLPatCit `xml:"patcit"`
+	NplCit   *XMLNplCit `xml:"nplcit"`
+	Category string     `xml:"category"`
+}
+
+type XMLPatCit struct {
+	Num       string `xml:"num,attr"`
+	Country   string `xml:"document-id>country"`
+	DocNumber string `xml:"document-id>doc-number"`
+	Kind      string `xml:"document-id>kind"`
+	Name      string `xml:"document-id>name"`
+	Date      string `xml:"document-id>date"`
+}
+
+type XMLNplCit struct {
+	Num      string `xml:"num,attr"`
+	Othercit struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"othercit"`
+}
+
+// XMLBibliographicRelations maps the family relations and priority claims of a patent document
+type XMLBibliographicRelations struct {
+	UsRelated  XMLRelatedDocuments `xml:"us-related-documents"`
+	Related    XMLRelatedDocuments `xml:"related-documents"`
+	Priorities []XMLPriorityClaim  `xml:"priority-claims>priority-claim"`
+}
+
+// XMLRelatedDocuments captures every child of us-related-documents, whose element name (continuation, division, reissue, ...) is the relation type
+type XMLRelatedDocuments struct {
+	Entries []XMLRelatedDocument `xml:",any"`
+}
+
+// XMLRelatedDocument is either a list of parent/child relations, or a single document-id for us-provisional-application and related-publication
+type XMLRelatedDocument struct {
+	XMLName    xml.Name
+	Relations  []XMLRelation  `xml:"relation"`
+	DocumentID *XMLDocumentID `xml:"document-id"`
+}
+
+type XMLRelation struct {
+	ParentDocumentID *XMLDocumentID `xml:"parent-doc>document-id"`
+	ParentStatus     string         `xml:"parent-doc>parent-status"`
+	ParentGrant      *XMLDocumentID `xml:"parent-doc>parent-grant-document>document-id"`
+	ParentPCT        *XMLDocumentID `xml:"parent-doc>parent-pct-document>document-id"`
+	Child            *XMLDocumentID `xml:"child-doc>document-id"`
+}
+
+type XMLDocumentID struct {
+	Country   string `xml:"country"`
+	DocNumber string `xml:"doc-number"`
+	Kind      string `xml:"kind"`
+	Date      string `xml:"date"`
+}
+
+type XMLPriorityClaim struct {
+	Sequence  string `xml:"sequence,attr"`
+	Kind      string `xml:"kind,attr"`
+	Country   string `xml:"country"`
+	DocNumber string `xml:"doc-number"`
+	Date      string `xml:"date"`
+}
+
+// XMLBibliographicProsecution maps the grant's term, examiner, PCT and field of search elements
+type XMLBibliographicProsecution struct {
+	TermOfGrant    *XMLTermOfGrant   `xml:"us-term-of-grant"`
+	Examiners      *XMLExaminers     `xml:"examiners"`
+	PCTFiling      *XMLPCTFiling     `xml:"pct-or-regional-filing-data"`
+	PCTPublication *XMLDocumentID    `xml:"pct-or-regional-publishing-data>document-id"`
+	UsFieldSearch  *XMLFieldOfSearch `xml:"us-field-of-classification-search"`
+	FieldSearch    *XMLFieldOfSearch `xml:"field-of-search"`
+}
+
+// XMLTermOfGrant keeps the numeric elements as text, so that a malformed value does not fail the whole document
+type XMLTermOfGrant struct {
+	Extension     string `xml:"us-term-extension"`
+	LengthOfGrant string `xml:"length-of-grant"`
+	Disclaimer    struct {
+		Inner string `xml:",innerxml"`
+	} `xml:"disclaimer"`
+}
+
+type XMLExaminers struct {
+	Primary   *XMLExaminer `xml:"primary-examiner"`
+	Assistant *XMLExaminer `xml:"assistant-examiner"`
+}
+
+type XMLExaminer struct {
+	LastName   string `xml:"last-name"`
+	FirstName  string `xml:"first-name"`
+	Department string `xml:"department"`
+}
+
+// XMLPCTFiling holds the PCT application and its 371 date, which is us-371c124-date before the AIA and us-371c12-date after
+type XMLPCTFiling struct {
+	DocumentID  XMLDocumentID `xml:"document-id"`
+	Date371c124 string        `xml:"us-371c124-date>date"`
+	Date371c12  string        `xml:"us-371c12-date>date"`
+}
+
+type XMLFieldOfSearch struct {
+	National []struct {
+		Country            string `xml:"country"`
+		MainClassification string `xml:"main-classification"`
+		AdditionalInfo     string `xml:"additional-info"`
+	} `xml:"classification-national"`
+	CPCText []string `xml:"classification-cpc-text"`
+}
+
+// XMLHeader maps the few bibliographic fields read to prefilter a document, so the rest of the section is skipped over
+type XMLHeader struct {
+	Publication XMLDocumentID `xml:"publication-reference>document-id"`
+	Application struct {
+		ApplType string `xml:"appl-type,attr"`
+	} `xml:"application-reference"`
+	National string `xml:"classification-national>main-classification"`
+	XMLBibliographicClassifications
+	Assignees []XMLAssignee `xml:"assignees>assignee"`
+}
@@ -0,0 +1,73 @@
+package models
+
+// XMLPAPBibliographic maps the <subdoc-bibliographic-information> section of the 2001-2004 pap-v15 and pap-v16 pre-grant publication schemas.
+type XMLPAPBibliographic struct {
+	DocNumber       string           `xml:"document-id>doc-number"`
+	KindCode        string           `xml:"document-id>kind-code"`
+	PublicationDate string           `xml:"document-id>document-date"`
+	FilingType      string           `xml:"publication-filing-type"`
+	ApplNumber      string           `xml:"domestic-filing-data>application-number>doc-number"`
+	ApplDate        string           `xml:"domestic-filing-data>filing-date"`
+	Divisions       []XMLPAPRelation `xml:"continuity-data>division-of>parent-child"`
+	Continuations   []XMLPAPRelation `xml:"continuity-data>continuations>continuation-of>parent-child"`
+	CIPs            []XMLPAPRelation `xml:"continuity-data>continuations>continuation-in-part-of>parent-child"`
+	Provisionals    []XMLPAPDocument `xml:"continuity-data>non-provisional-of-provisional"`
+	Priorities      []XMLPAPPriority `xml:"foreign-priority-data"`
+	IPCMain         string           `xml:"technical-information>classification-ipc>classification-ipc-primary>ipc"`
+	IPCFurther      []string         `xml:"technical-information>classification-ipc>classification-ipc-secondary>ipc"`
+	IPCEdition      string           `xml:"technical-information>classification-ipc>classification-ipc-edition"`
+	USMain          XMLPAPUSPC       `xml:"technical-information>classification-us>classification-us-primary>uspc"`
+	USFurther       []XMLPAPUSPC     `xml:"technical-information>classification-us>classification-us-secondary>uspc"`
+	Title           XMLInnerText     `xml:"technical-information>title-of-invention"`
+	FirstInventor   XMLPAPInventor   `xml:"inventors>first-named-inventor"`
+	Inventors       []XMLPAPInventor `xml:"inventors>inventor"`
+	Assignees       []XMLPAPAssignee `xml:"assignee"`
+}
+
+type XMLPAPUSPC struct {
+	Class    string `xml:"class"`
+	Subclass string `xml:"subclass"`
+}
+
+type XMLPAPInventor struct {
+	GivenName      string `xml:"name>given-name"`
+	MiddleName     string `xml:"name>middle-name"`
+	FamilyName     string `xml:"name>family-name"`
+	NameSuffix     string `xml:"name>name-suffix"`
+	City           string `xml:"residence>residence-us>city"`
+	State          string `xml:"residence>residence-us>state"`
+	Country        string `xml:"residence>residence-us>country-code"`
+	ForeignCity    string `xml:"residence>residence-non-us>city"`
+	ForeignState   string `xml:"residence>residence-non-us>state"`
+	ForeignCountry string `xml:"residence>residence-non-us>country-code"`
+}
+
+type XMLPAPAssignee struct {
+	Organization string `xml:"organization-name"`
+	GivenName    string `xml:"name>given-name"`
+	FamilyName   string `xml:"name>family-name"`
+	City         string `xml:"address>city"`
+	State        string `xml:"address>state"`
+	PostalCode   string `xml:"address>postalcode"`
+	Country      string `xml:"address>country>country-code"`
+	Type         string `xml:"assignee-type"`
+}
+
+type XMLPAPDocument struct {
+	DocNumber string `xml:"document-id>doc-number"`
+	Date      string `xml:"document-id>document-date"`
+}
+
+// XMLPAPRelation is the parent-child of a continuity-data relation: the child and parent applications, the parent's status and the patent granted on it
+type XMLPAPRelation struct {
+	Child  *XMLPAPDocument `xml:"child"`
+	Parent XMLPAPDocument  `xml:"parent"`
+	Status string          `xml:"parent-status"`
+	Grant  *XMLPAPDocument `xml:"parent-patent"`
+}
+
+type XMLPAPPriority struct {
+	DocNumber string `xml:"priority-application-number>doc-number"`
+	Date      string `xml:"filing-date"`
+	Country   string `xml:"country-code"`
+}
@@ -0,0 +1,92 @@
+package models
+
+// XMLV25Bibliographic maps the <SDOBI> bibliographic section of the 2001-2004 ST32-US-Grant-025xml.dtd (v2.5) grant format.
+// Element names follow the WIPO ST.32 "B" numbering, e.g. B110 is the document number and B721 an inventor.
+type XMLV25Bibliographic struct {
+	DocNumber       string           `xml:"B100>B110>DNUM>PDAT"`
+	KindCode        string           `xml:"B100>B130>PDAT"`
+	PublicationDate string           `xml:"B100>B140>DATE>PDAT"`
+	Country         string           `xml:"B100>B190>PDAT"`
+	ApplNumber      string           `xml:"B200>B210>DNUM>PDAT"`
+	ApplType        string           `xml:"B200>B211US>PDAT"`
+	ApplDate        string           `xml:"B200>B220>DATE>PDAT"`
+	Priorities      []XMLV25Priority `xml:"B300"`
+	IPCMain         string           `xml:"B500>B510>B511>PDAT"`
+	IPCFurther      []string         `xml:"B500>B510>B512>PDAT"`
+	USMain          string           `xml:"B500>B520>B521>PDAT"`
+	USFurther       []string         `xml:"B500>B520>B522>PDAT"`
+	Title           XMLInnerText     `xml:"B500>B540>STEXT"`
+	PatentCitations []XMLV25PCIT     `xml:"B500>B560>B561"`
+	OtherCitations  []XMLV25NCIT     `xml:"B500>B560>B562"`
+	NumberOfClaims  int              `xml:"B500>B570>B577>PDAT"`
+	Divisions       []XMLV25Parent   `xml:"B600>B620>PARENT-US"`
+	Continuations   []XMLV25Parent   `xml:"B600>B630>B631>PARENT-US"`
+	CIPs            []XMLV25Parent   `xml:"B600>B630>B632>PARENT-US"`
+	Reissues        []XMLV25Parent   `xml:"B600>B640>PARENT-US"`
+	Substitutions   []XMLV25Parent   `xml:"B600>B660>PARENT-US"`
+	Provisionals    []XMLV25Doc      `xml:"B600>B680US>DOC"`
+	Inventors       []XMLV25Party    `xml:"B700>B720>B721>PARTY-US"`
+	Assignees       []XMLV25Assignee `xml:"B700>B730"`
+	Agents          []XMLV25Party    `xml:"B700>B740>B741>PARTY-US"`
+}
+
+// XMLInnerText holds the raw content of a text element, such as a v2.5 STEXT whose PDAT runs may be split by inline markup
+type XMLInnerText struct {
+	Inner string `xml:",innerxml"`
+}
+
+type XMLV25Party struct {
+	FirstName    string `xml:"NAM>FNM>PDAT"`
+	LastName     string `xml:"NAM>SNM>STEXT>PDAT"`
+	Organization string `xml:"NAM>ONM>STEXT>PDAT"`
+	Street       string `xml:"ADR>STR>PDAT"`
+	City         string `xml:"ADR>CITY>PDAT"`
+	State        string `xml:"ADR>STATE>PDAT"`
+	PostalCode   string `xml:"ADR>PCODE>PDAT"`
+	Country      string `xml:"ADR>CTRY>PDAT"`
+}
+
+type XMLV25Assignee struct {
+	Party XMLV25Party `xml:"B731>PARTY-US"`
+	Role  string      `xml:"B732US>PDAT"`
+}
+
+type XMLV25PCIT struct {
+	DocNumber string `xml:"PCIT>DOC>DNUM>PDAT"`
+	KindCode  string `xml:"PCIT>DOC>KIND>PDAT"`
+	Date      string `xml:"PCIT>DOC>DATE>PDAT"`
+	Country   string `xml:"PCIT>DOC>CTRY>PDAT"`
+	Name      string `xml:"PCIT>PARTY-US>NAM>SNM>STEXT>PDAT"`
+	XMLV25CitedBy
+}
+
+type XMLV25NCIT struct {
+	Text XMLInnerText `xml:"NCIT>STEXT"`
+	XMLV25CitedBy
+}
+
+// XMLV25CitedBy captures the empty marker element that follows a v2.5 citation
+type XMLV25CitedBy struct {
+	Examiner *struct{} `xml:"CITED-BY-EXAMINER"`
+	Other    *struct{} `xml:"CITED-BY-OTHER"`
+}
+
+// XMLV25Priority is a B300 foreign priority claim
+type XMLV25Priority struct {
+	DocNumber string `xml:"B310>DNUM>PDAT"`
+	Date      string `xml:"B320>DATE>PDAT"`
+	Country   string `xml:"B330>CTRY>PDAT"`
+}
+
+type XMLV25Doc struct {
+	DocNumber string `xml:"DNUM>PDAT"`
+	Date      string `xml:"DATE>PDAT"`
+}
+
+// XMLV25Parent is the PARENT-US of a B600 related document: the child and parent applications, the parent's status and the patent granted on it
+type XMLV25Parent struct {
+	Child  *XMLV25Doc `xml:"CDOC>DOC"`
+	Parent XMLV25Doc  `xml:"PDOC>DOC"`
+	Status string     `xml:"PSTA>PDAT"`
+	Grant  *XMLV25Doc `xml:"PPUB>DOC"`
+}
@@ -0,0 +1,85 @@
+package apsparser
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// apsSegmentNames are the four character headers which open a new segment within an APS patent record
+var apsSegmentNames = map[string]bool{
+	"PATN": true, "INVT": true, "ASSG": true, "PRIR": true, "REIS": true, "RLAP": true,
+	"CLAS": true, "UREF": true, "FREF": true, "OREF": true, "LREP": true, "PCTA": true,
+	"ABST": true, "GOVT": true, "PARN": true, "BSUM": true, "DRWD": true, "DETD": true,
+	"CLMS": true, "DCLM": true,
+}
+
+// apsField is a single tagged line of an APS record, with any continuation lines appended to Value
+type apsField struct {
+	Name  string
+	Value string
+}
+
+// apsSegment is a segment header such as PATN, INVT or CLMS along with the fields that follow it
+type apsSegment struct {
+	Name   string
+	Fields []apsField
+}
+
+// Get returns the value of the first field in the segment with the given name
+func (s apsSegment) Get(name string) string {
+	for _, field := range s.Fields {
+		if field.Name == name {
+			return field.Value
+		}
+	}
+	return ""
+}
+
+// splitAPSSegments breaks a raw APS record into its segments.
+// Each line carries a field name in columns 1-4 and its value from column 6; lines with a blank name continue the previous field.
+func splitAPSSegments(rawRecord []byte) []apsSegment {
+	var segments []apsSegment
+
+	scanner := bufio.NewScanner(bytes.NewReader(rawRecord))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r ")
+		if line == "" {
+			continue
+		}
+
+		name := line
+		value := ""
+		if len(line) > 4 {
+			name = line[:4]
+			value = strings.TrimSpace(line[4:])
+		}
+		name = strings.TrimSpace(name)
+
+		// Segment header
+		if value == "" && apsSegmentNames[name] {
+			segments = append(segments, apsSegment{Name: name})
+			continue
+		}
+
+		// Fields appearing before any segment header are ignored
+		if len(segments) == 0 {
+			continue
+		}
+		current := &segments[len(segments)-1]
+
+		// Continuation line
+		if name == "" {
+			if n := len(current.Fields); n > 0 {
+				current.Fields[n-1].Value += " " + value
+			}
+			continue
+		}
+
+		current.Fields = append(current.Fields, apsField{Name: name, Value: value})
+	}
+
+	return segments
+}
@@ -0,0 +1,49 @@
+package apsparser
+
+import (
+	"errors"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// ReadHeader reads the header fields of a split APS record for prefiltering, from its PATN, CLAS and ASSG segments alone
+func ReadHeader(rawSplitDoc []byte) (types.DocHeader, error) {
+	header := types.DocHeader{DocumentType: "grant", Country: "US"}
+
+	segments := splitAPSSegments(rawSplitDoc)
+	if len(segments) == 0 || segments[0].Name != "PATN" {
+		return header, errors.New("aps record does not begin with a PATN segment")
+	}
+
+	for _, segment := range segments {
+		switch segment.Name {
+		case "PATN":
+			header.DocNumber = apsDocNumber(segment.Get("WKU"))
+			header.Date = segment.Get("ISD")
+			applType := apsApplTypes[segment.Get("APT")]
+			header.Kind = applType.KindCode
+			header.ApplType = applType.ApplType
+
+		case "CLAS":
+			header.USClassification = segment.Get("OCL")
+			for _, field := range segment.Fields {
+				if field.Name != "ICL" {
+					continue
+				}
+				if ipc, ok := types.ParseClassificationSymbol(field.Value); ok {
+					header.Classifications = append(header.Classifications, ipc.Symbol)
+				}
+			}
+
+		case "ASSG":
+			assignee := apsParty(segment)
+			switch {
+			case assignee.Organization != "":
+				header.Assignees = append(header.Assignees, assignee.Organization)
+			case assignee.LastName != "":
+				header.Assignees = append(header.Assignees, assignee.LastName)
+			}
+		}
+	}
+	return header, nil
+}
@@ -0,0 +1,52 @@
+package apsparser
+
+import (
+	"context"
+
+	"github.com/diverged/uspt-go/internal/utils"
+	"github.com/diverged/uspt-go/types"
+)
+
+// ParseAPSPatent parses each split APS record received on splitAPSDocChan, forwarding the parsed documents to parsedAPSDocChan.
+func ParseAPSPatent(ctx context.Context, cfg *types.USPTGoConfig, splitAPSDocChan <-chan *types.USPTGoDoc, parsedAPSDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
+
+	log.Debug("ParseAPSPatent has been invoked")
+
+	for doc := range splitAPSDocChan {
+
+		unmarshaledPatent, err := unmarshalAPSPatent(doc.RawSplitDoc, cfg.Projection, log)
+		if err != nil {
+			utils.SendErr(ctx, errChan, &types.USPTGoError{
+				Err:         err,
+				Kind:        types.ErrUnmarshal,
+				Name:        doc.USPTGoMetadata.OriginZip.IndexName,
+				Type:        "aps patent",
+				Whence:      "parsing APS record",
+				Skipped:     true,
+				DocID:       readDocID(doc.RawSplitDoc),
+				ByteOffset:  doc.USPTGoMetadata.OriginZip.ByteOffset,
+				ZipInfo:     doc.USPTGoMetadata.OriginZip,
+				RawSplitDoc: doc.RawSplitDoc,
+			})
+			continue
+		}
+
+		doc.Patent = unmarshaledPatent
+		doc.Patent.Normalize()
+
+		if !utils.SendDoc(ctx, parsedAPSDocChan, doc) {
+			utils.Drain(splitAPSDocChan)
+			return
+		}
+		log.Debug("ParseAPSPatent: doc => parsedAPSDocChan", "DocName", doc.USPTGoMetadata.OriginZip.IndexName)
+	}
+}
+
+// readDocID reads the publication number of a record which could not be parsed, for its error report.  It is empty when the PATN segment can't be read either.
+func readDocID(rawSplitDoc []byte) string {
+	header, err := ReadHeader(rawSplitDoc)
+	if err != nil {
+		return ""
+	}
+	return types.CanonicalDocNumber(header.Country, header.DocNumber, header.Kind)
+}
@@ -0,0 +1,416 @@
+package apsparser
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/diverged/uspt-go/internal/models"
+	"github.com/diverged/uspt-go/internal/parsers/claimtree"
+	"github.com/diverged/uspt-go/types"
+)
+
+// apsApplTypes maps the APS APT code to the appl-type and kind code used by the XML schemas
+var apsApplTypes = map[string]struct {
+	ApplType string
+	KindCode string
+}{
+	"1": {ApplType: "utility", KindCode: "A"},
+	"2": {ApplType: "reissue", KindCode: "E"},
+	"4": {ApplType: "design", KindCode: "S"},
+	"5": {ApplType: "defensive-publication"},
+	"6": {ApplType: "plant", KindCode: "P"},
+	"7": {ApplType: "sir", KindCode: "H"},
+}
+
+// UnmarshalAPSPatent maps a single split APS record onto the same types.Patent shape produced for XML documents.
+// Text sections are rendered as the <p>/<heading> markup used by the XML schemas so that they share the HTML translation stage.
+func UnmarshalAPSPatent(rawSplitDoc []byte, log types.Logger) (types.Patent, error) {
+	return unmarshalAPSPatent(rawSplitDoc, types.ProjectFullText, log)
+}
+
+// apsRelationCodes maps the parent code (COD) of an RLAP related application segment to its relation type
+var apsRelationCodes = map[string]types.RelationType{
+	"71": types.RelationContinuationInPart,
+	"72": types.RelationContinuation,
+	"73": types.RelationDivision,
+}
+
+// apsProjectedSegments lists the text segments parsed only when the projection includes their section
+var apsProjectedSegments = map[string]func(types.Projection) bool{
+	"ABST": types.Projection.IncludesAbstract,
+	"GOVT": types.Projection.IncludesDescription,
+	"PARN": types.Projection.IncludesDescription,
+	"BSUM": types.Projection.IncludesDescription,
+	"DRWD": types.Projection.IncludesDescription,
+	"DETD": types.Projection.IncludesDescription,
+	"CLMS": types.Projection.IncludesClaims,
+	"DCLM": types.Projection.IncludesClaims,
+}
+
+func unmarshalAPSPatent(rawSplitDoc []byte, projection types.Projection, log types.Logger) (types.Patent, error) {
+	var patent types.Patent
+
+	log.Debug("UnmarshalAPSPatent has been called")
+
+	segments := splitAPSSegments(rawSplitDoc)
+	if len(segments) == 0 || segments[0].Name != "PATN" {
+		return types.Patent{}, errors.New("aps record does not begin with a PATN segment")
+	}
+
+	patent.MetaCountry = "US"
+	biblio := &patent.UsBibliographicData
+
+	var (
+		abstract    strings.Builder
+		description strings.Builder
+		claimsText  strings.Builder
+		claims      []*models.Claim
+		paraCount   int
+	)
+
+	for _, segment := range segments {
+		if included, ok := apsProjectedSegments[segment.Name]; ok && !included(projection) {
+			continue
+		}
+
+		switch segment.Name {
+		case "PATN":
+			patentNumber := segment.Get("WKU")
+			if patentNumber == "" {
+				return types.Patent{}, errors.New("aps record is missing its WKU patent number")
+			}
+			biblio.PublicationReference.DocumentID.Country = "US"
+			biblio.PublicationReference.DocumentID.DocNumber = apsDocNumber(patentNumber)
+			biblio.PublicationReference.DocumentID.Date = segment.Get("ISD")
+			patent.MetaDatePubl = segment.Get("ISD")
+
+			applType := apsApplTypes[segment.Get("APT")]
+			biblio.PublicationReference.DocumentID.KindCode = applType.KindCode
+			biblio.ApplicationReference.ApplType = applType.ApplType
+			biblio.ApplicationReference.DocumentID.Country = "US"
+			biblio.ApplicationReference.DocumentID.DocNumber = apsApplicationNumber(segment.Get("SRC"), segment.Get("APN"))
+			biblio.ApplicationReference.DocumentID.Date = segment.Get("APD")
+
+			if primary := segment.Get("EXP"); primary != "" {
+				last, first := splitAPSName(primary)
+				biblio.Examiners = &types.Examiners{Primary: &types.Examiner{LastName: last, FirstName: first}}
+				if assistant := segment.Get("EXA"); assistant != "" {
+					last, first := splitAPSName(assistant)
+					biblio.Examiners.Assistant = &types.Examiner{LastName: last, FirstName: first}
+				}
+			}
+
+			title := segment.Get("TTL")
+			biblio.InventionTitle.Text = title
+			biblio.InventionTitle.Content = html.EscapeString(title)
+
+			if ncl := segment.Get("NCL"); ncl != "" {
+				numberOfClaims, err := strconv.Atoi(ncl)
+				if err != nil {
+					log.Warn("APS record has a non-numeric NCL field", "NCL", ncl)
+				}
+				biblio.NumberOfClaims = numberOfClaims
+			}
+
+		case "INVT":
+			biblio.Parties.Inventors = append(biblio.Parties.Inventors, apsParty(segment))
+
+		case "ASSG":
+			assignee := apsParty(segment)
+			assignee.Role = segment.Get("COD")
+			biblio.Assignees = append(biblio.Assignees, assignee)
+
+		case "LREP":
+			// Legal representatives are recorded as either a firm (FRM) or one or more attorneys (FR2, AAT, AGT)
+			for _, field := range segment.Fields {
+				switch field.Name {
+				case "FRM":
+					biblio.Parties.Agents = append(biblio.Parties.Agents, types.Party{Organization: field.Value})
+				case "FR2", "AAT", "AGT":
+					last, first := splitAPSName(field.Value)
+					biblio.Parties.Agents = append(biblio.Parties.Agents, types.Party{LastName: last, FirstName: first})
+				}
+			}
+
+		case "PRIR":
+			biblio.PriorityClaims = append(biblio.PriorityClaims, types.PriorityClaim{
+				Country:   segment.Get("CNT"),
+				DocNumber: segment.Get("APN"),
+				Date:      segment.Get("APD"),
+			})
+
+		case "RLAP":
+			relationType, ok := apsRelationCodes[segment.Get("COD")]
+			if !ok {
+				log.Warn("APS record has an unrecognized RLAP parent code", "COD", segment.Get("COD"))
+				continue
+			}
+			biblio.RelatedDocuments = append(biblio.RelatedDocuments, types.RelatedDocument{
+				Type:         relationType,
+				Parent:       types.DocumentID{Country: "US", DocNumber: segment.Get("APN"), Date: segment.Get("APD")},
+				ParentStatus: segment.Get("STA"),
+				ParentGrant:  apsParentGrant(segment),
+			})
+
+		case "REIS":
+			// The original patent which this patent reissues, and its application
+			biblio.RelatedDocuments = append(biblio.RelatedDocuments, types.RelatedDocument{
+				Type:        types.RelationReissue,
+				Parent:      types.DocumentID{Country: "US", DocNumber: segment.Get("APN"), Date: segment.Get("APD")},
+				ParentGrant: apsParentGrant(segment),
+			})
+
+		case "CLAS":
+			biblio.ClassificationNational.Country = "US"
+			biblio.ClassificationNational.MainClassification = segment.Get("OCL")
+			for _, field := range segment.Fields {
+				switch field.Name {
+				case "XCL":
+					biblio.ClassificationNational.FurtherClassifications = append(biblio.ClassificationNational.FurtherClassifications, field.Value)
+				case "ICL":
+					// The first ICL is the main IPC classification
+					if ipc, ok := types.ParseClassificationSymbol(field.Value); ok {
+						ipc.Main = len(biblio.Classifications.IPCR) == 0
+						biblio.Classifications.IPCR = append(biblio.Classifications.IPCR, ipc)
+					}
+				}
+			}
+			if len(biblio.ClassificationNational.FurtherClassifications) > 0 {
+				biblio.ClassificationNational.FurtherClassification = biblio.ClassificationNational.FurtherClassifications[0]
+			}
+
+		case "UREF":
+			patent.Citations.Patent = append(patent.Citations.Patent, types.PatentCitation{
+				Country:   "US",
+				DocNumber: segment.Get("PNO"),
+				Name:      segment.Get("NAM"),
+				Date:      segment.Get("ISD"),
+			})
+
+		case "FREF":
+			patent.Citations.Patent = append(patent.Citations.Patent, types.PatentCitation{
+				Country:   segment.Get("CNT"),
+				DocNumber: segment.Get("PNO"),
+				Date:      segment.Get("ISD"),
+			})
+
+		case "OREF":
+			// Other references are free text, one paragraph field per reference
+			for _, field := range segment.Fields {
+				if isAPSParagraph(field.Name) {
+					patent.Citations.NonPatent = append(patent.Citations.NonPatent, types.NonPatentCitation{Text: field.Value})
+				}
+			}
+
+		case "ABST":
+			for _, field := range segment.Fields {
+				if isAPSParagraph(field.Name) {
+					paraCount++
+					fmt.Fprintf(&abstract, `<p id="p-%04d" num="%04d">%s</p>`, paraCount, paraCount, html.EscapeString(field.Value))
+				}
+			}
+
+		case "GOVT", "PARN", "BSUM", "DRWD", "DETD":
+			if segment.Name == "DRWD" {
+				description.WriteString(`<description-of-drawings>`)
+			}
+			for _, field := range segment.Fields {
+				switch {
+				case field.Name == "PAC":
+					fmt.Fprintf(&description, `<heading level="1">%s</heading>`, html.EscapeString(field.Value))
+				case isAPSParagraph(field.Name):
+					paraCount++
+					fmt.Fprintf(&description, `<p id="p-%04d" num="%04d">%s</p>`, paraCount, paraCount, html.EscapeString(field.Value))
+				}
+			}
+			if segment.Name == "DRWD" {
+				description.WriteString(`</description-of-drawings>`)
+			}
+
+		case "CLMS", "DCLM":
+			claims = append(claims, apsClaims(segment)...)
+		}
+	}
+
+	patent.Abstract.Content = abstract.String()
+	patent.Description.Content = description.String()
+
+	// Resolve claim dependencies from the claim text, then link the claim tree
+	for _, claim := range claims {
+		fmt.Fprintf(&claimsText, `<claim id="%s"><claim-text>%s</claim-text></claim>`, claim.ID, html.EscapeString(strings.Join(claim.Text, " ")))
+	}
+	claimtree.Build(claims)
+
+	patent.Claims.Content = claimsText.String()
+	patent.StructuredClaims = claims
+
+	return patent, nil
+}
+
+// apsClaims builds claims from a CLMS (or DCLM) segment.  Each NUM field opens a new claim, and the paragraph fields which follow become its text.
+func apsClaims(segment apsSegment) []*models.Claim {
+	var claims []*models.Claim
+	var current *models.Claim
+	var currentNum int
+
+	newClaim := func(num int) *models.Claim {
+		return &models.Claim{
+			ID:   apsClaimID(num),
+			Type: "INDEPENDENT",
+			Text: []string{},
+		}
+	}
+
+	for _, field := range segment.Fields {
+		switch {
+		case field.Name == "NUM":
+			num, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(field.Value), "."))
+			if err != nil {
+				num = len(claims) + 1
+			}
+			currentNum = num
+			current = newClaim(num)
+			claims = append(claims, current)
+
+		case isAPSParagraph(field.Name):
+			// Design claims (DCLM) have no NUM field
+			if current == nil {
+				currentNum = 1
+				current = newClaim(currentNum)
+				claims = append(claims, current)
+			}
+			current.Text = append(current.Text, field.Value)
+
+			for _, parentNum := range apsClaimReferences(field.Value, currentNum) {
+				parentID := apsClaimID(parentNum)
+				if !containsString(current.ClaimTree.ParentIds, parentID) {
+					current.Type = "DEPENDENT"
+					current.ClaimTree.ParentIds = append(current.ClaimTree.ParentIds, parentID)
+					current.ClaimTree.ParentCount++
+				}
+			}
+		}
+	}
+
+	return claims
+}
+
+var (
+	apsClaimRefRegex    = regexp.MustCompile(`(?i)\bclaims?\s+(\d+(?:\s*(?:,|-|to|through|or|and|and/or)\s*\d+)*)`)
+	apsClaimNumberRegex = regexp.MustCompile(`\d+|-|to|through`)
+)
+
+// apsClaimReferences extracts the claim numbers referenced in text such as "as claimed in claim 1" or "any of claims 2-4".
+// Only references to earlier claims are returned, which excludes the claim's own number.
+func apsClaimReferences(text string, ownNum int) []int {
+	var refs []int
+	for _, match := range apsClaimRefRegex.FindAllStringSubmatch(text, -1) {
+		tokens := apsClaimNumberRegex.FindAllString(match[1], -1)
+		for i := 0; i < len(tokens); i++ {
+			num, err := strconv.Atoi(tokens[i])
+			if err != nil {
+				continue
+			}
+			// Expand ranges such as "2-4" or "2 to 4"
+			if i+2 < len(tokens) && (tokens[i+1] == "-" || tokens[i+1] == "to" || tokens[i+1] == "through") {
+				if end, err := strconv.Atoi(tokens[i+2]); err == nil && end > num {
+					for n := num; n <= end; n++ {
+						if n < ownNum {
+							refs = append(refs, n)
+						}
+					}
+					i += 2
+					continue
+				}
+			}
+			if num < ownNum {
+				refs = append(refs, num)
+			}
+		}
+	}
+	return refs
+}
+
+// apsParty maps the NAM, STR, CTY, STA, CNT and ZIP fields shared by the INVT and ASSG segments
+func apsParty(segment apsSegment) types.Party {
+	party := types.Party{
+		Address: types.Address{
+			Street:     segment.Get("STR"),
+			City:       segment.Get("CTY"),
+			State:      segment.Get("STA"),
+			PostalCode: segment.Get("ZIP"),
+			Country:    segment.Get("CNT"),
+		},
+	}
+	if party.Address.Country == "" && party.Address.State != "" {
+		party.Address.Country = "US"
+	}
+
+	// Individuals are written "Last; First".  An assignee without the semicolon is an organization, while an inventor is always a person, of whom only a last name may be given.
+	name := segment.Get("NAM")
+	if segment.Name == "ASSG" && !strings.Contains(name, ";") {
+		party.Organization = name
+		return party
+	}
+	party.LastName, party.FirstName = splitAPSName(name)
+	return party
+}
+
+// splitAPSName splits an APS "Last; First" name
+func splitAPSName(name string) (last, first string) {
+	last, first, _ = strings.Cut(name, ";")
+	return strings.TrimSpace(last), strings.TrimSpace(first)
+}
+
+// apsDocNumber drops the trailing check digit from the nine character WKU, giving the eight character doc-number used by the XML schemas
+func apsDocNumber(wku string) string {
+	wku = strings.TrimSpace(wku)
+	if len(wku) == 9 {
+		return wku[:8]
+	}
+	return wku
+}
+
+// apsApplicationNumber combines the series code with the six digit APN (dropping its check digit), e.g. SRC "5" and APN "4918880" => "05491888"
+func apsApplicationNumber(src, apn string) string {
+	src = strings.TrimSpace(src)
+	apn = strings.TrimSpace(apn)
+	if len(apn) > 6 {
+		apn = apn[:6]
+	}
+	if len(src) == 1 && src[0] >= '0' && src[0] <= '9' {
+		src = "0" + src
+	}
+	return src + apn
+}
+
+// apsParentGrant reads the patent (PNO) issued (ISD) on the parent application of an RLAP or REIS segment, which is nil when it has none
+func apsParentGrant(segment apsSegment) *types.DocumentID {
+	pno := segment.Get("PNO")
+	if pno == "" {
+		return nil
+	}
+	return &types.DocumentID{Country: "US", DocNumber: pno, Date: segment.Get("ISD")}
+}
+
+func apsClaimID(num int) string {
+	return fmt.Sprintf("CLM-%05d", num)
+}
+
+// isAPSParagraph reports whether a field holds paragraph text, e.g. PAR, PA1, PAL, TBL or EQU
+func isAPSParagraph(name string) bool {
+	return strings.HasPrefix(name, "PA") && name != "PAC" || name == "TBL" || name == "EQU"
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package apsparser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// mockLogger implements the Logger interface for testing purposes.
+type mockLogger struct{}
+
+func (m *mockLogger) Debug(msg string, keysAndValues ...interface{}) {}
+func (m *mockLogger) Info(msg string, keysAndValues ...interface{})  {}
+func (m *mockLogger) Warn(msg string, keysAndValues ...interface{})  {}
+func (m *mockLogger) Error(msg string, keysAndValues ...interface{}) {}
+
+const sampleAPSRecord = `PATN
+WKU  039304848
+SRC  5
+APN  4918880
+APT  1
+ART  353
+APD  19741111
+TTL  Apparatus for projecting images
+ISD  19760106
+NCL  3
+ECL  1
+INVT
+NAM  Doe; John A.
+CTY  Anytown
+STA  CA
+INVT
+NAM  Roe; Richard
+CTY  Osaka
+CNT  JPX
+ASSG
+NAM  Acme Projection Corp.
+CTY  Anytown
+STA  CA
+COD  02
+PRIR
+CNT  DEX
+APD  19730412
+APN  P2318529.6
+CLAS
+OCL  353 25
+XCL  353 26
+ICL  G03B 2132
+ICL  G03B 2114
+UREF
+PNO  2628432
+ISD  19530200
+NAM  Cox
+OCL  353 25
+OREF
+PAL  Brown, "Projection Optics," Optical Review, vol. 3,
+     pp. 12-15, 1970.
+ABST
+PAL  An apparatus for projecting images & text
+     onto a screen.
+BSUM
+PAC  BACKGROUND OF THE INVENTION
+PAR  Projectors are known.
+DETD
+PAR  The apparatus comprises a lamp.
+CLMS
+STM  What is claimed is:
+NUM  1.
+PAR  1. An apparatus comprising a lamp.
+NUM  2.
+PAR  2. The apparatus as claimed in claim 1 further comprising:
+PA1  a lens.
+NUM  3.
+PAR  3. The apparatus of any one of claims 1-2, wherein the lamp is halogen.`
+
+func TestUnmarshalAPSPatent(t *testing.T) {
+	patent, err := UnmarshalAPSPatent([]byte(sampleAPSRecord), &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalAPSPatent returned an error: %v", err)
+	}
+
+	biblio := patent.UsBibliographicData
+	if got := biblio.PublicationReference.DocumentID.DocNumber; got != "03930484" {
+		t.Errorf("DocNumber = %q, want %q", got, "03930484")
+	}
+	if got := biblio.PublicationReference.DocumentID.KindCode; got != "A" {
+		t.Errorf("KindCode = %q, want %q", got, "A")
+	}
+	if got := biblio.ApplicationReference.DocumentID.DocNumber; got != "05491888" {
+		t.Errorf("application DocNumber = %q, want %q", got, "05491888")
+	}
+	if got := patent.MetaDatePubl; got != "19760106" {
+		t.Errorf("MetaDatePubl = %q, want %q", got, "19760106")
+	}
+	if got := biblio.InventionTitle.Text; got != "Apparatus for projecting images" {
+		t.Errorf("InventionTitle = %q", got)
+	}
+	if biblio.NumberOfClaims != 3 {
+		t.Errorf("NumberOfClaims = %d, want 3", biblio.NumberOfClaims)
+	}
+
+	if len(biblio.Parties.Inventors) != 2 {
+		t.Fatalf("expected 2 inventors, got %d", len(biblio.Parties.Inventors))
+	}
+	if inv := biblio.Parties.Inventors[0]; inv.LastName != "Doe" || inv.FirstName != "John A." || inv.Address.Country != "US" {
+		t.Errorf("unexpected first inventor: %+v", inv)
+	}
+	if len(biblio.Assignees) != 1 || biblio.Assignees[0].Organization != "Acme Projection Corp." || biblio.Assignees[0].Role != "02" {
+		t.Errorf("unexpected assignees: %+v", biblio.Assignees)
+	}
+	if biblio.ClassificationNational.MainClassification != "353 25" {
+		t.Errorf("MainClassification = %q", biblio.ClassificationNational.MainClassification)
+	}
+	if priority := biblio.PriorityClaims; len(priority) != 1 || priority[0].Country != "DEX" || priority[0].Date != "19730412" {
+		t.Errorf("unexpected priority claims: %+v", priority)
+	}
+	if ipcr := biblio.Classifications.IPCR; len(ipcr) != 2 || ipcr[0].Symbol != "G03B 21/32" || !ipcr[0].Main || ipcr[1].Main {
+		t.Errorf("unexpected IPC classifications: %+v", ipcr)
+	}
+	if len(patent.Citations.Patent) != 1 || patent.Citations.Patent[0].DocNumber != "2628432" {
+		t.Errorf("unexpected citations: %+v", patent.Citations.Patent)
+	}
+	if len(patent.Citations.NonPatent) != 1 || !strings.HasSuffix(patent.Citations.NonPatent[0].Text, "pp. 12-15, 1970.") {
+		t.Errorf("unexpected non-patent citations: %+v", patent.Citations.NonPatent)
+	}
+
+	if !strings.Contains(patent.Abstract.Content, "images &amp; text onto a screen.") {
+		t.Errorf("abstract continuation lines not joined and escaped: %q", patent.Abstract.Content)
+	}
+	if !strings.Contains(patent.Description.Content, `<heading level="1">BACKGROUND OF THE INVENTION</heading>`) {
+		t.Errorf("description heading missing: %q", patent.Description.Content)
+	}
+
+	claims := patent.StructuredClaims
+	if len(claims) != 3 {
+		t.Fatalf("expected 3 claims, got %d", len(claims))
+	}
+	if claims[0].Type != "INDEPENDENT" || claims[0].ClaimTree.ChildCount != 2 {
+		t.Errorf("unexpected claim 1: %+v", claims[0])
+	}
+	if claims[1].Type != "DEPENDENT" || len(claims[1].Text) != 2 || claims[1].ClaimTree.ClaimTreeLevel != 1 {
+		t.Errorf("unexpected claim 2: %+v", claims[1])
+	}
+	if claims[2].Type != "DEPENDENT" || claims[2].ClaimTree.ParentCount != 2 {
+		t.Errorf("unexpected claim 3: %+v", claims[2])
+	}
+}
+
+func TestUnmarshalAPSPatentPartyNames(t *testing.T) {
+	record := `PATN
+WKU  039304848
+APN  4918880
+ISD  19760106
+INVT
+NAM  Doe; John A.
+INVT
+NAM  Teller
+ASSG
+NAM  Roe; Richard
+COD  04
+ASSG
+NAM  Acme Projection Corp.
+COD  02`
+
+	patent, err := UnmarshalAPSPatent([]byte(record), &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalAPSPatent returned an error: %v", err)
+	}
+
+	biblio := patent.UsBibliographicData
+	want := []types.Party{
+		{LastName: "Doe", FirstName: "John A."},
+		{LastName: "Teller"},
+		{LastName: "Roe", FirstName: "Richard", Role: "04"},
+		{Organization: "Acme Projection Corp.", Role: "02"},
+	}
+	got := append(append([]types.Party{}, biblio.Parties.Inventors...), biblio.Assignees...)
+	if len(got) != len(want) {
+		t.Fatalf("expected %d parties, got %+v", len(want), got)
+	}
+	for i := range want {
+		if got[i].LastName != want[i].LastName || got[i].FirstName != want[i].FirstName || got[i].Organization != want[i].Organization || got[i].Role != want[i].Role {
+			t.Errorf("party %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestUnmarshalAPSPatentRelations(t *testing.T) {
+	record := `PATN
+WKU  RE0288621
+APN  5612345
+APT  2
+APD  19750801
+ISD  19760615
+REIS
+COD  
+APN  5401234
+APD  19730310
+PNO  3800000
+ISD  19740326
+RLAP
+COD  72
+STA  1
+APN  5301234
+APD  19720105
+PNO  3700000
+ISD  19730101
+RLAP
+COD  73
+APN  5201234
+APD  19710520
+`
+	patent, err := UnmarshalAPSPatent([]byte(record), &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalAPSPatent returned an error: %v", err)
+	}
+
+	related := patent.UsBibliographicData.RelatedDocuments
+	if len(related) != 3 {
+		t.Fatalf("expected 3 related documents, got %+v", related)
+	}
+	if reissue := related[0]; reissue.Type != types.RelationReissue || reissue.Parent.DocNumber != "5401234" || reissue.ParentGrant == nil || reissue.ParentGrant.DocNumber != "3800000" {
+		t.Errorf("unexpected reissue: %+v", reissue)
+	}
+	if continuation := related[1]; continuation.Type != types.RelationContinuation || continuation.Parent.Date != "19720105" || continuation.ParentStatus != "1" ||
+		continuation.ParentGrant == nil || continuation.ParentGrant.Date != "19730101" {
+		t.Errorf("unexpected continuation: %+v", continuation)
+	}
+	if division := related[2]; division.Type != types.RelationDivision || division.Parent.DocNumber != "5201234" || division.ParentGrant != nil {
+		t.Errorf("unexpected division: %+v", division)
+	}
+}
+
+func TestUnmarshalAPSPatentRequiresPATN(t *testing.T) {
+	if _, err := UnmarshalAPSPatent([]byte("HHHHHT APS1.0\n"), &mockLogger{}); err == nil {
+		t.Error("expected an error for a record without a PATN segment")
+	}
+}
+
+func TestReadHeader(t *testing.T) {
+	header, err := ReadHeader([]byte(sampleAPSRecord))
+	if err != nil {
+		t.Fatalf("ReadHeader returned an error: %v", err)
+	}
+
+	want := types.DocHeader{DocumentType: "grant", Country: "US", DocNumber: "03930484", Kind: "A", Date: "19760106", ApplType: "utility",
+		Classifications: []string{"G03B 21/32", "G03B 21/14"}, USClassification: "353 25", Assignees: []string{"Acme Projection Corp."}}
+	if got := fmt.Sprintf("%+v", header); got != fmt.Sprintf("%+v", want) {
+		t.Errorf("got  %s\nwant %s", got, fmt.Sprintf("%+v", want))
+	}
+
+	if _, err := ReadHeader([]byte("ABST\nPAL  No PATN segment.")); err == nil {
+		t.Error("expected an error for a record without a PATN segment")
+	}
+}
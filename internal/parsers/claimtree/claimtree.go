@@ -0,0 +1,43 @@
+package claimtree
+
+import (
+	"github.com/diverged/uspt-go/internal/models"
+)
+
+// Build links each claim to its parents via ParentIds, filling ChildIds, ChildCount and ClaimTreeLevel.
+// Every parser producing []*models.Claim calls Build once the claims and their ParentIds are known.
+func Build(claims []*models.Claim) {
+
+	// Build the claim tree
+	for _, claim := range claims {
+		for _, parentID := range claim.ClaimTree.ParentIds {
+			for _, parent := range claims {
+				if parent.ID == parentID {
+					parent.ChildIds = append(parent.ChildIds, claim.ID)
+					parent.ClaimTree.ChildCount++
+					break
+				}
+			}
+		}
+	}
+
+	// Calculate claim tree levels
+	var calculateClaimTreeLevel func(*models.Claim, int)
+	calculateClaimTreeLevel = func(claim *models.Claim, level int) {
+		claim.ClaimTree.ClaimTreeLevel = level
+		for _, childID := range claim.ChildIds {
+			for _, child := range claims {
+				if child.ID == childID {
+					calculateClaimTreeLevel(child, level+1)
+					break
+				}
+			}
+		}
+	}
+
+	for _, claim := range claims {
+		if claim.Type == "INDEPENDENT" {
+			calculateClaimTreeLevel(claim, 0)
+		}
+	}
+}
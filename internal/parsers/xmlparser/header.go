@@ -0,0 +1,110 @@
+package xmlparser
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	"github.com/diverged/uspt-go/internal/models"
+	"github.com/diverged/uspt-go/types"
+)
+
+// ReadHeader reads the header fields of a split patent document for prefiltering, decoding its bibliographic section alone
+func ReadHeader(rawSplitDoc []byte, documentType string, format types.SchemaFormat) (types.DocHeader, error) {
+	header := types.DocHeader{DocumentType: documentType}
+
+	decoder := newLenientDecoder(rawSplitDoc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return header, errors.New("document has no bibliographic section")
+		}
+		if err != nil {
+			return header, err
+		}
+
+		startElement, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case format == types.FormatST32 && startElement.Name.Local == "SDOBI":
+			var biblio models.XMLV25Bibliographic
+			if err := decoder.DecodeElement(&biblio, &startElement); err != nil {
+				return header, err
+			}
+			header.Country = "US"
+			header.DocNumber = biblio.DocNumber
+			header.Kind = biblio.KindCode
+			header.Date = biblio.PublicationDate
+			header.USClassification = biblio.USMain
+			header.Classifications = classificationSymbols(legacyIPC(biblio.IPCMain, biblio.IPCFurther))
+			for _, assignee := range biblio.Assignees {
+				header.Assignees = appendName(header.Assignees, assignee.Party.Organization, assignee.Party.LastName, assignee.Party.FirstName)
+			}
+			return header, nil
+
+		case format == types.FormatPAP && startElement.Name.Local == "subdoc-bibliographic-information":
+			var biblio models.XMLPAPBibliographic
+			if err := decoder.DecodeElement(&biblio, &startElement); err != nil {
+				return header, err
+			}
+			header.Country = "US"
+			header.DocNumber = biblio.DocNumber
+			header.Kind = biblio.KindCode
+			header.Date = biblio.PublicationDate
+			header.ApplType = strings.TrimPrefix(biblio.FilingType, "new-")
+			header.USClassification = papUSPC(biblio.USMain)
+			header.Classifications = classificationSymbols(legacyIPC(biblio.IPCMain, biblio.IPCFurther))
+			for _, assignee := range biblio.Assignees {
+				header.Assignees = appendName(header.Assignees, assignee.Organization, assignee.FamilyName, assignee.GivenName)
+			}
+			return header, nil
+
+		case startElement.Name.Local == "us-bibliographic-data-grant", startElement.Name.Local == "us-bibliographic-data-application":
+			var biblio models.XMLHeader
+			if err := decoder.DecodeElement(&biblio, &startElement); err != nil {
+				return header, err
+			}
+			header.Country = biblio.Publication.Country
+			header.DocNumber = biblio.Publication.DocNumber
+			header.Kind = biblio.Publication.Kind
+			header.Date = biblio.Publication.Date
+			header.ApplType = biblio.Application.ApplType
+			header.USClassification = biblio.National
+			classifications := mapXMLClassifications(biblio.XMLBibliographicClassifications)
+			header.Classifications = classificationSymbols(append(classifications.IPCR, classifications.CPC...))
+			for _, assignee := range biblio.Assignees {
+				organization := assignee.Addressbook.Orgname
+				if organization == "" {
+					organization = assignee.Orgname
+				}
+				header.Assignees = appendName(header.Assignees, organization, assignee.Addressbook.LastName, assignee.Addressbook.FirstName)
+			}
+			return header, nil
+		}
+	}
+}
+
+func classificationSymbols(classifications []types.Classification) []string {
+	var symbols []string
+	for _, classification := range classifications {
+		if classification.Symbol != "" {
+			symbols = append(symbols, classification.Symbol)
+		}
+	}
+	return symbols
+}
+
+// appendName appends the organization, or failing that the individual's name
+func appendName(names []string, organization, lastName, firstName string) []string {
+	if organization != "" {
+		return append(names, organization)
+	}
+	if name := strings.TrimSpace(strings.Join([]string{firstName, lastName}, " ")); name != "" {
+		return append(names, name)
+	}
+	return names
+}
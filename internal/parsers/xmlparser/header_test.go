@@ -0,0 +1,55 @@
+package xmlparser
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+func TestReadHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		format types.SchemaFormat
+		want   types.DocHeader
+	}{
+		{
+			name:   "v4.5 grant",
+			raw:    sampleGrantV45,
+			format: types.FormatUSPatent,
+			want: types.DocHeader{DocumentType: "grant", Country: "US", DocNumber: "10000001", Kind: "B2", Date: "20180619", ApplType: "utility",
+				Classifications: []string{"B29C 45/17", "B29C 45/1775", "B29C 2045/1784"}, USClassification: "425542", Assignees: []string{"Acme Molding Co."}},
+		},
+		{
+			name:   "v2.5 grant",
+			raw:    sampleV25Patent,
+			format: types.FormatST32,
+			want:   types.DocHeader{DocumentType: "grant", Country: "US", DocNumber: "06334220", Kind: "B1", Date: "20020101", USClassification: "606232", Assignees: []string{"Acme Medical"}},
+		},
+		{
+			name:   "pre-grant publication",
+			raw:    samplePAPPatent,
+			format: types.FormatPAP,
+			want:   types.DocHeader{DocumentType: "application", Country: "US", DocNumber: "20020000001", Kind: "A1", Date: "20020103", ApplType: "utility", USClassification: "172677000", Assignees: []string{"Farm Tools Inc."}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, err := ReadHeader([]byte(tt.raw), tt.want.DocumentType, tt.format)
+			if err != nil {
+				t.Fatalf("ReadHeader returned an error: %v", err)
+			}
+			if got, want := fmt.Sprintf("%+v", header), fmt.Sprintf("%+v", tt.want); got != want {
+				t.Errorf("got  %s\nwant %s", got, want)
+			}
+		})
+	}
+}
+
+func TestReadHeaderWithoutBibliographicData(t *testing.T) {
+	if _, err := ReadHeader([]byte(`<?xml version="1.0"?><us-patent-grant></us-patent-grant>`), "grant", types.FormatUSPatent); err == nil {
+		t.Error("expected an error for a document without a bibliographic section")
+	}
+}
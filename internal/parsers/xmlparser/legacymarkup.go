@@ -0,0 +1,226 @@
+package xmlparser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/diverged/uspt-go/internal/models"
+	"github.com/diverged/uspt-go/types"
+)
+
+// legacyMarkup describes how the text elements of a pre-2005 schema map onto the <heading>, <p> and <claim> markup of the current schemas,
+// so that legacy documents share the downstream claim parsing and XML to HTML translation.
+type legacyMarkup struct {
+	Headings     map[string]bool   // Elements rendered as <heading level="...">
+	Paragraphs   map[string]bool   // Elements rendered as <p id="..." num="...">
+	Inline       map[string]string // Inline formatting elements and their HTML replacement, e.g. BOLD => b
+	Wrappers     map[string]string // Container elements kept as current schema containers, e.g. DRWDESC => description-of-drawings
+	Skip         map[string]bool   // Elements whose content is dropped entirely
+	LevelAttr    string            // Attribute carrying the heading level
+	Claim        string            // Element holding a single claim
+	ClaimText    string            // Element holding claim text, nested for claim elements/steps
+	ClaimRef     string            // Element referencing a parent claim
+	ClaimRefAttr string            // Attribute of ClaimRef holding the parent claim id
+}
+
+// legacyText is the result of walking a legacy text section
+type legacyText struct {
+	Content string          // Current schema markup
+	Claims  []*models.Claim // Structured claims, when the section contains claims
+}
+
+// newLenientDecoder returns a decoder tolerant of the SGML-derived markup and HTML entities found in pre-2005 bulk files
+func newLenientDecoder(raw []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
+
+// walk is called after the section start element has been read, and consumes tokens up to and including its end element, rendering the section as current schema markup.
+func (m legacyMarkup) walk(decoder *xml.Decoder) (legacyText, error) {
+	var (
+		out     strings.Builder
+		claims  []*models.Claim
+		closers []string // Closing markup for each open element, popped on the matching end element
+		kinds   []string // The role of each open element, for claim bookkeeping
+
+		claim      *models.Claim
+		claimTexts []*strings.Builder // Open claim text segments; the innermost receives character data
+		claimSteps int                // Nested claim text elements already opened within the current claim
+		refDepth   int                // Open claim references, within which claim text elements are transparent
+		skipDepth  int
+	)
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return legacyText{}, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return legacyText{}, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			kind := ""
+			closer := ""
+
+			switch {
+			case skipDepth > 0 || m.Skip[name]:
+				kind = "skip"
+				skipDepth++
+
+			case name == m.Claim:
+				kind = "claim"
+				id := legacyAttr(t, "ID", "id")
+				claim = &models.Claim{ID: id, Type: "INDEPENDENT", Text: []string{""}}
+				claims = append(claims, claim)
+				claimTexts = []*strings.Builder{{}}
+				claimSteps = 0
+				fmt.Fprintf(&out, `<claim id="%s" num="%s"><claim-text>`, html.EscapeString(id), legacyNum(id))
+				closer = `</claim-text></claim>`
+
+			case name == m.ClaimText && claim != nil && refDepth == 0:
+				// The first top level claim text holds the claim's main text; any others are nested elements
+				if claimSteps == 0 && claimTexts[0].Len() == 0 && kinds[len(kinds)-1] == "claim" {
+					kind = "claim-main"
+				} else {
+					kind = "claim-step"
+					claimTexts = append(claimTexts, &strings.Builder{})
+					out.WriteString(`<claim-text>`)
+					closer = `</claim-text>`
+				}
+				claimSteps++
+
+			case name == m.ClaimRef && claim != nil:
+				kind = "claim-ref"
+				refDepth++
+				parentID := legacyAttr(t, m.ClaimRefAttr)
+				if parentID != "" && parentID != claim.ID {
+					claim.Type = "DEPENDENT"
+					claim.ClaimTree.ParentIds = append(claim.ClaimTree.ParentIds, parentID)
+					claim.ClaimTree.ParentCount++
+				}
+				fmt.Fprintf(&out, `<claim-ref idref="%s">`, html.EscapeString(parentID))
+				closer = `</claim-ref>`
+
+			case m.Headings[name]:
+				fmt.Fprintf(&out, `<heading level="%s">`, html.EscapeString(legacyAttr(t, m.LevelAttr)))
+				closer = `</heading>`
+
+			case m.Paragraphs[name]:
+				id := strings.ToLower(legacyAttr(t, "ID", "id"))
+				fmt.Fprintf(&out, `<p id="%s" num="%s">`, html.EscapeString(id), legacyNum(id))
+				closer = `</p>`
+
+			case m.Inline[name] != "":
+				fmt.Fprintf(&out, `<%s>`, m.Inline[name])
+				closer = fmt.Sprintf(`</%s>`, m.Inline[name])
+
+			case m.Wrappers[name] != "":
+				fmt.Fprintf(&out, `<%s>`, m.Wrappers[name])
+				closer = fmt.Sprintf(`</%s>`, m.Wrappers[name])
+			}
+
+			kinds = append(kinds, kind)
+			closers = append(closers, closer)
+
+		case xml.EndElement:
+			// The end of the section itself
+			if len(closers) == 0 {
+				return legacyText{Content: out.String(), Claims: claims}, nil
+			}
+
+			kind := kinds[len(kinds)-1]
+			out.WriteString(closers[len(closers)-1])
+			kinds = kinds[:len(kinds)-1]
+			closers = closers[:len(closers)-1]
+
+			switch kind {
+			case "skip":
+				skipDepth--
+			case "claim":
+				claim.Text[0] = cleanLegacyText(claimTexts[0].String())
+				claim = nil
+				claimTexts = nil
+			case "claim-step":
+				step := claimTexts[len(claimTexts)-1]
+				claimTexts = claimTexts[:len(claimTexts)-1]
+				claim.Text = append(claim.Text, cleanLegacyText(step.String()))
+			case "claim-ref":
+				refDepth--
+			}
+
+		case xml.CharData:
+			if skipDepth > 0 {
+				continue
+			}
+			text := string(t)
+			out.WriteString(html.EscapeString(text))
+			if claim != nil && len(claimTexts) > 0 {
+				claimTexts[len(claimTexts)-1].WriteString(text)
+			}
+		}
+	}
+}
+
+var legacyWhitespaceRegex = regexp.MustCompile(`\s+`)
+
+func cleanLegacyText(text string) string {
+	return strings.TrimSpace(legacyWhitespaceRegex.ReplaceAllString(text, " "))
+}
+
+// legacyAttr returns the first non-empty attribute matching one of names
+func legacyAttr(element xml.StartElement, names ...string) string {
+	for _, name := range names {
+		for _, attr := range element.Attr {
+			if attr.Name.Local == name && attr.Value != "" {
+				return attr.Value
+			}
+		}
+	}
+	return ""
+}
+
+// legacyNum extracts the numeric suffix of an id such as CLM-00001 or P-0012
+func legacyNum(id string) string {
+	if i := strings.LastIndex(id, "-"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
+
+// legacyPlainText strips markup from a raw innerxml string, e.g. a title split into several PDAT runs
+func legacyPlainText(innerXML string) string {
+	var sb strings.Builder
+	decoder := newLenientDecoder([]byte(innerXML))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		if charData, ok := token.(xml.CharData); ok {
+			sb.Write(charData)
+		}
+	}
+	return cleanLegacyText(sb.String())
+}
+
+// legacyIPC structures the compact IPC symbols of the pre-2005 schemas, marking the primary symbol as Main
+func legacyIPC(main string, further []string) []types.Classification {
+	var classifications []types.Classification
+	for i, symbol := range append([]string{main}, further...) {
+		if classification, ok := types.ParseClassificationSymbol(symbol); ok {
+			classification.Main = i == 0
+			classifications = append(classifications, classification)
+		}
+	}
+	return classifications
+}
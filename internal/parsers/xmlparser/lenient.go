@@ -0,0 +1,88 @@
+package xmlparser
+
+import (
+	"github.com/diverged/uspt-go/types"
+)
+
+// unmarshaler unmarshals a raw XML document of a single schema format
+type unmarshaler func(rawSplitDoc []byte, log types.Logger) (types.Patent, error)
+
+// salvageSections unmarshals rawSplitDoc without the abstract, description or claims sections which made unmarshalErr fail the whole document.
+// The fewest sections which let the rest of the document unmarshal are cut, each reported as a Diagnostic, and the document as unmarshaled returned.  It fails when even the bibliographic data alone can't be unmarshaled.
+func salvageSections(rawSplitDoc []byte, format types.SchemaFormat, unmarshal unmarshaler, unmarshalErr error, log types.Logger) ([]byte, types.Patent, []types.Diagnostic, error) {
+	sections, ok := projectionSections[format]
+	if !ok {
+		return nil, types.Patent{}, nil, unmarshalErr
+	}
+
+	// Only the sections present can be cut, in the order a broken section is most likely to be found
+	var present []sectionElement
+	for _, section := range []sectionElement{
+		{types.SectionDescription, sections.Description},
+		{types.SectionClaims, sections.Claims},
+		{types.SectionAbstract, sections.Abstract},
+	} {
+		if indexStartTag(rawSplitDoc, section.element) >= 0 {
+			present = append(present, section)
+		}
+	}
+
+	// The trial unmarshals are expected to fail, so their errors are only logged at debug level
+	quiet := debugLogger{log}
+	for _, cut := range subsetsBySize(present) {
+		salvaged, copied, complete := rawSplitDoc, false, true
+		for _, section := range cut {
+			var removed bool
+			salvaged, removed = cutElement(salvaged, section.element, !copied)
+			copied = copied || removed
+			complete = complete && removed
+		}
+		if !complete {
+			continue // A section without an end can't be cut
+		}
+		patent, err := unmarshal(salvaged, quiet)
+		if err != nil {
+			continue
+		}
+
+		diagnostics := make([]types.Diagnostic, len(cut))
+		for i, section := range cut {
+			diagnostics[i] = types.Diagnostic{Section: section.section, Code: types.ErrUnmarshal.Code(), Message: unmarshalErr.Error()}
+		}
+		return salvaged, patent, diagnostics, nil
+	}
+	return nil, types.Patent{}, nil, unmarshalErr
+}
+
+type sectionElement struct {
+	section types.Section
+	element string
+}
+
+// subsetsBySize lists the non-empty subsets of sections, smallest first
+func subsetsBySize(sections []sectionElement) [][]sectionElement {
+	var subsets [][]sectionElement
+	for size := 1; size <= len(sections); size++ {
+		for mask := 1; mask < 1<<len(sections); mask++ {
+			var subset []sectionElement
+			for i, section := range sections {
+				if mask&(1<<i) != 0 {
+					subset = append(subset, section)
+				}
+			}
+			if len(subset) == size {
+				subsets = append(subsets, subset)
+			}
+		}
+	}
+	return subsets
+}
+
+// debugLogger demotes the errors logged through it to debug messages
+type debugLogger struct {
+	types.Logger
+}
+
+func (l debugLogger) Error(msg string, keysAndValues ...interface{}) {
+	l.Logger.Debug(msg, keysAndValues...)
+}
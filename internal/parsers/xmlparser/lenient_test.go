@@ -0,0 +1,96 @@
+package xmlparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+func TestSalvageSections(t *testing.T) {
+	unmarshal := func(rawSplitDoc []byte, log types.Logger) (types.Patent, error) {
+		return UnmarshalXmlPatent(rawSplitDoc, "grant", nil, log)
+	}
+
+	tests := []struct {
+		name     string
+		broken   string
+		fixed    string
+		sections []types.Section
+	}{
+		{
+			name:     "Broken claims",
+			broken:   strings.Replace(sampleGrantV45, "</claim></claims>", "</claims>", 1),
+			sections: []types.Section{types.SectionClaims},
+		},
+		{
+			name:     "Broken abstract and description",
+			broken:   strings.NewReplacer("A machine.</p></abstract>", "A machine.</abstract>", "Text.</p></description>", "Text.</description>").Replace(sampleGrantV45),
+			sections: []types.Section{types.SectionDescription, types.SectionAbstract},
+		},
+		{
+			name:   "Broken bibliographic data",
+			broken: strings.Replace(sampleGrantV45, "</invention-title>", "", 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw := []byte(tt.broken)
+			_, unmarshalErr := unmarshal(raw, &mockLogger{})
+			if unmarshalErr == nil {
+				t.Fatal("expected the broken document to fail to unmarshal")
+			}
+
+			salvaged, patent, diagnostics, err := salvageSections(raw, types.FormatUSPatent, unmarshal, unmarshalErr, &mockLogger{})
+			if tt.sections == nil {
+				if err == nil {
+					t.Errorf("expected the document to be beyond salvage, got diagnostics %v", diagnostics)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("salvageSections returned an error: %v", err)
+			}
+			if string(raw) != tt.broken {
+				t.Error("salvageSections modified the raw document")
+			}
+
+			var sections []types.Section
+			for _, diagnostic := range diagnostics {
+				if diagnostic.Code != "unmarshal" || diagnostic.Message != unmarshalErr.Error() {
+					t.Errorf("unexpected diagnostic: %+v", diagnostic)
+				}
+				sections = append(sections, diagnostic.Section)
+			}
+			if len(sections) != len(tt.sections) || strings.Join(sectionNames(sections), ",") != strings.Join(sectionNames(tt.sections), ",") {
+				t.Errorf("expected sections %v to be cut, got %v", tt.sections, sections)
+			}
+
+			cut := make(map[types.Section]bool)
+			for _, section := range sections {
+				cut[section] = true
+			}
+			if got := patent.Claims.Content != ""; got == cut[types.SectionClaims] {
+				t.Errorf("claims parsed: %v, cut: %v", got, cut[types.SectionClaims])
+			}
+			if got := patent.Abstract.Content != ""; got == cut[types.SectionAbstract] {
+				t.Errorf("abstract parsed: %v, cut: %v", got, cut[types.SectionAbstract])
+			}
+			if got := patent.Description.Content != ""; got == cut[types.SectionDescription] {
+				t.Errorf("description parsed: %v, cut: %v", got, cut[types.SectionDescription])
+			}
+			if patent.UsBibliographicData.InventionTitle.Content == "" || len(salvaged) >= len(raw) {
+				t.Errorf("expected the bibliographic data of a shorter document, got title %q", patent.UsBibliographicData.InventionTitle.Content)
+			}
+		})
+	}
+}
+
+func sectionNames(sections []types.Section) []string {
+	names := make([]string, len(sections))
+	for i, section := range sections {
+		names[i] = string(section)
+	}
+	return names
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/diverged/uspt-go/internal/models"
+	"github.com/diverged/uspt-go/internal/parsers/claimtree"
 	"github.com/diverged/uspt-go/types"
 )
 
@@ -23,8 +24,8 @@ func ParseStructuredClaims(rawXmlClaims []byte, log types.Logger) ([]*models.Cla
 				// EOF is expected, so we can break without reporting an error.
 				break
 			}
-			log.Error("Error within ParseStructuredClaims when decoding token:", err)
-			break
+			log.Error("Error within ParseStructuredClaims when decoding token", "error", err)
+			return nil, err
 		}
 
 		if startElement, ok := token.(xml.StartElement); ok && startElement.Name.Local == "claim" {
@@ -87,38 +88,8 @@ func ParseStructuredClaims(rawXmlClaims []byte, log types.Logger) ([]*models.Cla
 		claims = append(claims, claim)
 	}
 
-	// Build the claim tree
-	for _, claim := range claims {
-		for _, parentID := range claim.ClaimTree.ParentIds {
-			for _, parent := range claims {
-				if parent.ID == parentID {
-					parent.ChildIds = append(parent.ChildIds, claim.ID)
-					parent.ClaimTree.ChildCount++
-					break
-				}
-			}
-		}
-	}
-
-	// Calculate claim tree levels
-	var calculateClaimTreeLevel func(*models.Claim, int)
-	calculateClaimTreeLevel = func(claim *models.Claim, level int) {
-		claim.ClaimTree.ClaimTreeLevel = level
-		for _, childID := range claim.ChildIds {
-			for _, child := range claims {
-				if child.ID == childID {
-					calculateClaimTreeLevel(child, level+1)
-					break
-				}
-			}
-		}
-	}
-
-	for _, claim := range claims {
-		if claim.Type == "INDEPENDENT" {
-			calculateClaimTreeLevel(claim, 0)
-		}
-	}
+	// Link parents and children, then calculate claim tree levels
+	claimtree.Build(claims)
 
 	/* 	// Print the claim data
 	   	for _, claim := range claims {
@@ -3,16 +3,18 @@ package xmlparser
 import (
 	// "bytes"
 
+	"context"
 	"errors"
 	"fmt"
 
 	// "io"
 	"strings"
 
+	"github.com/diverged/uspt-go/internal/utils"
 	"github.com/diverged/uspt-go/types"
 )
 
-func ParseXMLPatent(cfg *types.USPTGoConfig, splitXMLDocChan <-chan *types.USPTGoDoc, parsedXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
+func ParseXMLPatent(ctx context.Context, cfg *types.USPTGoConfig, splitXMLDocChan <-chan *types.USPTGoDoc, parsedXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
 
 	log.Debug("ParseXMLPatent has been invoked")
 
@@ -21,58 +23,104 @@ func ParseXMLPatent(cfg *types.USPTGoConfig, splitXMLDocChan <-chan *types.USPTG
 		// fmt.Println(string(doc.RawSplitDoc))
 
 		// log.Debug("Parsing split XML document", zap.String("DocName", doc.GoUSPTOMetadata.SplitterIndex["DocIndexOfZip"]))
+		schema, _ := types.LookupSchema(doc.USPTGoMetadata.OriginZip.Schema)
+
 		var (
-			happyParser = true            // Abort flag to enable skipping the document
-			parseErrors []error           // Accumulates any encountered parsing errors
-			rawSplitDoc = doc.RawSplitDoc // Extract the raw XML document from the XMLDocument
+			happyParser = true                                                       // Abort flag to enable skipping the document
+			parseErrors []error                                                      // Accumulates any encountered parsing errors
+			rawSplitDoc = projectXML(doc.RawSplitDoc, schema.Format, cfg.Projection) // Extract the raw XML document, less any sections excluded by the projection
 		)
 
 		// * Initial Unmarshaling
 
-		unmarshaledPatent, err := UnmarshalXmlPatent(rawSplitDoc, doc.USPTGoMetadata.DocumentType, errChan, log)
-		if err != nil {
-			errChan <- &types.USPTGoError{
-				Err:     err,
-				Name:    doc.USPTGoMetadata.OriginZip.IndexName,
-				Type:    "xml patent",
-				Whence:  "unmarshaling XML document",
-				Skipped: true,
+		var (
+			unmarshal    unmarshaler
+			legacySchema = true // Pre-2005 schemas have dedicated parsers which also produce the structured claims
+		)
+
+		switch schema.Format {
+		case types.FormatST32:
+			unmarshal = UnmarshalV25Patent
+		case types.FormatPAP:
+			unmarshal = UnmarshalPAPPatent
+		default:
+			legacySchema = false
+			unmarshal = func(rawSplitDoc []byte, log types.Logger) (types.Patent, error) {
+				return UnmarshalXmlPatent(rawSplitDoc, doc.USPTGoMetadata.DocumentType, errChan, log)
 			}
+		}
+		unmarshaledPatent, err := unmarshal(rawSplitDoc, log)
+
+		// * In lenient mode, retry without the sections which broke the document
+		if err != nil && cfg.Lenient {
+			salvaged, patent, diagnostics, salvageErr := salvageSections(rawSplitDoc, schema.Format, unmarshal, err, log)
+			if salvageErr == nil {
+				log.Warn("Parsed XML document without its broken sections", "DocName", doc.USPTGoMetadata.OriginZip.IndexName, "sections", len(diagnostics), "error", err)
+				rawSplitDoc, unmarshaledPatent, err = salvaged, patent, nil
+				doc.Diagnostics = append(doc.Diagnostics, diagnostics...)
+			}
+		}
+		if err != nil {
+			utils.SendErr(ctx, errChan, &types.USPTGoError{
+				Err:         err,
+				Kind:        types.ErrUnmarshal,
+				Name:        doc.USPTGoMetadata.OriginZip.IndexName,
+				Type:        "xml patent",
+				Whence:      "unmarshaling XML document",
+				Skipped:     true,
+				DocID:       readDocID(doc, schema.Format),
+				ByteOffset:  doc.USPTGoMetadata.OriginZip.ByteOffset,
+				ZipInfo:     doc.USPTGoMetadata.OriginZip,
+				RawSplitDoc: doc.RawSplitDoc,
+			})
 			continue
 		}
 
 		doc.Patent = unmarshaledPatent
+		doc.Patent.Normalize()
 
 		// fmt.Println(doc.Patent.Description.Content)
 
 		// * Map the Claims Tree
-		structuredClaims, err := ParseStructuredClaims(rawSplitDoc, log)
-		if err != nil {
-			parseErrors = append(parseErrors, fmt.Errorf("failed to parse structured claims from extracted xml claims []byte slice: %w", err))
-			happyParser = false
-		}
-
-		// fmt.Println(structuredClaims)
+		if !legacySchema && cfg.Projection.IncludesClaims() {
+			structuredClaims, err := ParseStructuredClaims(rawSplitDoc, log)
+			switch {
+			case err != nil && cfg.Lenient:
+				// The claims text stands, without its structure
+				doc.Diagnostics = append(doc.Diagnostics, types.Diagnostic{Section: types.SectionClaims, Code: types.ErrClaims.Code(), Message: err.Error()})
+			case err != nil:
+				parseErrors = append(parseErrors, fmt.Errorf("failed to parse structured claims from extracted xml claims []byte slice: %w", err))
+				happyParser = false
+			}
 
-		// Assign the structured claims to the doc
-		doc.Patent.StructuredClaims = structuredClaims
+			// Assign the structured claims to the doc
+			doc.Patent.StructuredClaims = structuredClaims
+		}
 
-		// * If parser is not happy, collect the parsing error(s) and report the skipped document to errChan
+		// * If parser is not happy, collect the parsing error(s) and report the skipped document to errChan.  Only the structured claims are parsed after unmarshaling.
 		if !happyParser {
 			combinedError := combineErrors(parseErrors)
-			errChan <- &types.USPTGoError{
-				Err:     combinedError,
-				Name:    doc.USPTGoMetadata.OriginZip.IndexName,
-				Type:    "xml patent",
-				Whence:  "parsing XML document",
-				Skipped: true,
-			}
+			utils.SendErr(ctx, errChan, &types.USPTGoError{
+				Err:         combinedError,
+				Kind:        types.ErrClaims,
+				Name:        doc.USPTGoMetadata.OriginZip.IndexName,
+				Type:        "xml patent",
+				Whence:      "parsing XML document",
+				Skipped:     true,
+				DocID:       doc.Patent.Normalized.PublicationNumber,
+				ByteOffset:  doc.USPTGoMetadata.OriginZip.ByteOffset,
+				ZipInfo:     doc.USPTGoMetadata.OriginZip,
+				RawSplitDoc: doc.RawSplitDoc,
+			})
 			// `continue` bypasses the remaining code in the loop and starts the next iteration, effectively blocking the document from ever being sent into parsedXMLDocChan
 			continue
 		}
 
-		// * Send the parsed XML document to the channel
-		parsedXMLDocChan <- doc
+		// * Send the parsed XML document to the channel, unless the run has been cancelled
+		if !utils.SendDoc(ctx, parsedXMLDocChan, doc) {
+			utils.Drain(splitXMLDocChan)
+			return
+		}
 		log.Debug("ParseXMLElements: doc => parsedXMLDocChan", "DocName", doc.USPTGoMetadata.OriginZip.IndexName)
 
 	} // End of range over channel
@@ -87,6 +135,15 @@ func combineErrors(parseErrors []error) error {
 	return errors.New(strings.Join(errMsgs, "; "))
 }
 
+// readDocID reads the publication number of a document which could not be unmarshaled, for its error report.  It is empty when the header can't be read either.
+func readDocID(doc *types.USPTGoDoc, format types.SchemaFormat) string {
+	header, err := ReadHeader(doc.RawSplitDoc, doc.USPTGoMetadata.DocumentType, format)
+	if err != nil {
+		return ""
+	}
+	return types.CanonicalDocNumber(header.Country, header.DocNumber, header.Kind)
+}
+
 /* func ParseXMLPatent(cfg *types.USPTGoConfig, splitXMLDocChan <-chan *types.USPTGoDoc, parsedXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
 
 	log.Debug("ParseXMLPatent has been invoked")
@@ -0,0 +1,68 @@
+package xmlparser
+
+import (
+	"context"
+	"encoding/xml"
+	"strings"
+
+	"github.com/diverged/uspt-go/internal/utils"
+	"github.com/diverged/uspt-go/types"
+)
+
+// ParseXMLTrademark parses each split trademark <case-file> received on splitXMLDocChan, forwarding the parsed documents to parsedXMLDocChan.
+func ParseXMLTrademark(ctx context.Context, cfg *types.USPTGoConfig, splitXMLDocChan <-chan *types.USPTGoDoc, parsedXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
+
+	log.Debug("ParseXMLTrademark has been invoked")
+
+	for doc := range splitXMLDocChan {
+
+		trademark, err := UnmarshalXmlTrademark(doc.Trademark.RawSplitDoc, log)
+		if err != nil {
+			utils.SendErr(ctx, errChan, &types.USPTGoError{
+				Err:         err,
+				Kind:        types.ErrUnmarshal,
+				Name:        doc.USPTGoMetadata.OriginZip.IndexName,
+				Type:        "xml trademark",
+				Whence:      "unmarshaling XML case-file",
+				Skipped:     true,
+				ByteOffset:  doc.USPTGoMetadata.OriginZip.ByteOffset,
+				ZipInfo:     doc.USPTGoMetadata.OriginZip,
+				RawSplitDoc: doc.Trademark.RawSplitDoc,
+			})
+			continue
+		}
+
+		doc.Trademark = trademark
+
+		if !utils.SendDoc(ctx, parsedXMLDocChan, doc) {
+			utils.Drain(splitXMLDocChan)
+			return
+		}
+		log.Debug("ParseXMLTrademark: doc => parsedXMLDocChan", "DocName", doc.USPTGoMetadata.OriginZip.IndexName)
+	}
+}
+
+// UnmarshalXmlTrademark unmarshals a single <case-file> and derives its goods and services from the "GS" statements
+func UnmarshalXmlTrademark(rawSplitDoc []byte, log types.Logger) (types.Trademark, error) {
+	var trademark types.Trademark
+
+	log.Debug("UnmarshalXmlTrademark has been called")
+
+	if err := xml.Unmarshal(rawSplitDoc, &trademark); err != nil {
+		log.Error("Error unmarshaling xml trademark", "error", err)
+		return types.Trademark{}, err
+	}
+	trademark.RawSplitDoc = rawSplitDoc
+
+	// Goods and services statements carry type codes such as GS0091, where 009 is the international class
+	for _, statement := range trademark.Statements {
+		if strings.HasPrefix(statement.TypeCode, "GS") && len(statement.TypeCode) >= 5 {
+			trademark.GoodsAndServices = append(trademark.GoodsAndServices, types.TrademarkGoodsAndServices{
+				Class: statement.TypeCode[2:5],
+				Text:  statement.Text,
+			})
+		}
+	}
+
+	return trademark, nil
+}
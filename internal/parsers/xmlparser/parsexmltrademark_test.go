@@ -0,0 +1,62 @@
+package xmlparser
+
+import (
+	"testing"
+)
+
+const sampleTrademarkCaseFile = `<case-file>
+<serial-number>87654321</serial-number>
+<registration-number>5123456</registration-number>
+<transaction-date>20180102</transaction-date>
+<case-file-header>
+<filing-date>20171020</filing-date>
+<registration-date>20180102</registration-date>
+<status-code>700</status-code>
+<status-date>20180102</status-date>
+<mark-identification>WIDGETRON</mark-identification>
+<mark-drawing-code>4000</mark-drawing-code>
+</case-file-header>
+<case-file-statements>
+<case-file-statement><type-code>GS0091</type-code><text>Computer software</text></case-file-statement>
+<case-file-statement><type-code>D00000</type-code><text>No claim is made to the exclusive right to use "TRON"</text></case-file-statement>
+</case-file-statements>
+<case-file-event-statements>
+<case-file-event-statement><code>NWAP</code><type>I</type><description-text>NEW APPLICATION ENTERED</description-text><date>20171024</date><number>1</number></case-file-event-statement>
+<case-file-event-statement><code>R.PR</code><type>A</type><description-text>REGISTERED-PRINCIPAL REGISTER</description-text><date>20180102</date><number>2</number></case-file-event-statement>
+</case-file-event-statements>
+<classifications>
+<classification><international-code>009</international-code><us-code>021</us-code><us-code>023</us-code><status-code>6</status-code><primary-code>009</primary-code></classification>
+</classifications>
+<case-file-owners>
+<case-file-owner><entry-number>01</entry-number><party-type>30</party-type><nationality><state>DE</state></nationality><legal-entity-type-code>03</legal-entity-type-code><party-name>Widgetron LLC</party-name><city>Wilmington</city><state>DE</state></case-file-owner>
+</case-file-owners>
+</case-file>`
+
+func TestUnmarshalXmlTrademark(t *testing.T) {
+	trademark, err := UnmarshalXmlTrademark([]byte(sampleTrademarkCaseFile), &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalXmlTrademark returned an error: %v", err)
+	}
+
+	if trademark.SerialNumber != "87654321" || trademark.RegistrationNumber != "5123456" {
+		t.Errorf("unexpected numbers: %q, %q", trademark.SerialNumber, trademark.RegistrationNumber)
+	}
+	if trademark.Header.MarkIdentification != "WIDGETRON" || trademark.Header.StatusCode != "700" {
+		t.Errorf("unexpected header: %+v", trademark.Header)
+	}
+	if len(trademark.GoodsAndServices) != 1 || trademark.GoodsAndServices[0].Class != "009" || trademark.GoodsAndServices[0].Text != "Computer software" {
+		t.Errorf("unexpected goods and services: %+v", trademark.GoodsAndServices)
+	}
+	if len(trademark.Events) != 2 || trademark.Events[1].Code != "R.PR" || trademark.Events[1].Number != 2 {
+		t.Errorf("unexpected events: %+v", trademark.Events)
+	}
+	if len(trademark.Classifications) != 1 || len(trademark.Classifications[0].USCodes) != 2 || trademark.Classifications[0].PrimaryCode != "009" {
+		t.Errorf("unexpected classifications: %+v", trademark.Classifications)
+	}
+	if len(trademark.Owners) != 1 || trademark.Owners[0].PartyName != "Widgetron LLC" || trademark.Owners[0].NationalityState != "DE" {
+		t.Errorf("unexpected owners: %+v", trademark.Owners)
+	}
+	if len(trademark.RawSplitDoc) == 0 {
+		t.Error("RawSplitDoc was not retained")
+	}
+}
@@ -0,0 +1,86 @@
+package xmlparser
+
+import (
+	"bytes"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// projectionSections names the abstract, description and claims elements of each schema format
+var projectionSections = map[types.SchemaFormat]struct{ Abstract, Description, Claims string }{
+	types.FormatUSPatent: {Abstract: "abstract", Description: "description", Claims: "claims"},
+	types.FormatST32:     {Abstract: "SDOAB", Description: "SDODE", Claims: "SDOCL"},
+	types.FormatPAP:      {Abstract: "subdoc-abstract", Description: "subdoc-description", Claims: "subdoc-claims"},
+}
+
+// projectXML returns rawSplitDoc without the sections excluded by projection, so they are never unmarshaled.  rawSplitDoc itself is left untouched.
+func projectXML(rawSplitDoc []byte, format types.SchemaFormat, projection types.Projection) []byte {
+	sections, ok := projectionSections[format]
+	if !ok || projection == types.ProjectFullText {
+		return rawSplitDoc
+	}
+
+	var cut []string
+	if !projection.IncludesDescription() {
+		cut = append(cut, sections.Description)
+	}
+	if !projection.IncludesAbstract() {
+		cut = append(cut, sections.Abstract)
+	}
+	if !projection.IncludesClaims() {
+		cut = append(cut, sections.Claims)
+	}
+
+	projected, copied := rawSplitDoc, false
+	for _, name := range cut {
+		var removed bool
+		projected, removed = cutElement(projected, name, !copied)
+		copied = copied || removed
+	}
+	return projected
+}
+
+// cutElement removes the first <name>...</name> element of doc, copying doc first unless it may be modified in place, and reports whether the element was removed.
+// An element whose end can't be found is left in place, rather than cutting the rest of the document with it.
+func cutElement(doc []byte, name string, copyFirst bool) ([]byte, bool) {
+	start := indexStartTag(doc, name)
+	if start < 0 {
+		return doc, false
+	}
+
+	end := -1
+	closing := []byte("</" + name + ">")
+	if i := bytes.Index(doc[start:], closing); i >= 0 {
+		end = start + i + len(closing)
+	} else if i := bytes.Index(doc[start:], []byte("/>")); i >= 0 && bytes.IndexByte(doc[start:start+i], '>') < 0 {
+		end = start + i + 2 // A self-closing element
+	}
+	if end < 0 {
+		return doc, false
+	}
+
+	if copyFirst {
+		projected := make([]byte, 0, len(doc)-(end-start))
+		return append(append(projected, doc[:start]...), doc[end:]...), true
+	}
+	return append(doc[:start], doc[end:]...), true
+}
+
+// indexStartTag finds the start tag of the named element, which must be followed by whitespace, '>' or "/>" so that e.g. <claims> does not match <claims-statement>
+func indexStartTag(doc []byte, name string) int {
+	tag := []byte("<" + name)
+	for offset := 0; ; {
+		i := bytes.Index(doc[offset:], tag)
+		if i < 0 {
+			return -1
+		}
+		start := offset + i
+		if next := start + len(tag); next < len(doc) {
+			switch doc[next] {
+			case '>', '/', ' ', '\t', '\n', '\r':
+				return start
+			}
+		}
+		offset = start + len(tag)
+	}
+}
@@ -0,0 +1,80 @@
+package xmlparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+func TestProjectXML(t *testing.T) {
+	raw := []byte(sampleGrantV45)
+
+	tests := []struct {
+		projection                    types.Projection
+		abstract, description, claims bool
+	}{
+		{types.ProjectFullText, true, true, true},
+		{types.ProjectAbstract, true, false, true},
+		{types.ProjectClaims, false, false, true},
+		{types.ProjectBibliographic, false, false, false},
+	}
+
+	for _, tt := range tests {
+		projected := projectXML(raw, types.FormatUSPatent, tt.projection)
+		patent, err := UnmarshalXmlPatent(projected, "grant", nil, &mockLogger{})
+		if err != nil {
+			t.Fatalf("projection %d: UnmarshalXmlPatent returned an error: %v", tt.projection, err)
+		}
+
+		if got := patent.Abstract.Content != ""; got != tt.abstract {
+			t.Errorf("projection %d: abstract present = %v, want %v", tt.projection, got, tt.abstract)
+		}
+		if got := patent.Description.Content != ""; got != tt.description {
+			t.Errorf("projection %d: description present = %v, want %v", tt.projection, got, tt.description)
+		}
+		if got := patent.Claims.Content != ""; got != tt.claims {
+			t.Errorf("projection %d: claims present = %v, want %v", tt.projection, got, tt.claims)
+		}
+		if patent.UsBibliographicData.InventionTitle.Text == "" || patent.UsBibliographicData.NumberOfClaims != 2 {
+			t.Errorf("projection %d: bibliographic data was lost", tt.projection)
+		}
+	}
+
+	// The raw document is never modified, as it may be returned to the caller
+	if string(raw) != sampleGrantV45 {
+		t.Error("projectXML modified its input")
+	}
+}
+
+func TestProjectXMLLegacy(t *testing.T) {
+	patent, err := UnmarshalPAPPatent(projectXML([]byte(samplePAPPatent), types.FormatPAP, types.ProjectBibliographic), &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalPAPPatent returned an error: %v", err)
+	}
+	if patent.Description.Content != "" || patent.Abstract.Content != "" || len(patent.StructuredClaims) != 0 {
+		t.Error("expected the text sections to be cut")
+	}
+	if patent.UsBibliographicData.InventionTitle.Text != "Tillage implement" {
+		t.Errorf("InventionTitle = %q", patent.UsBibliographicData.InventionTitle.Text)
+	}
+}
+
+func TestCutElementMatchesWholeNames(t *testing.T) {
+	doc := []byte(`<a><claims-statement>kept</claims-statement><claims id="c"><claim/></claims></a>`)
+	projected, removed := cutElement(doc, "claims", true)
+	if !removed || string(projected) != `<a><claims-statement>kept</claims-statement></a>` {
+		t.Errorf("cutElement() = %q, %v", projected, removed)
+	}
+	if !strings.Contains(string(doc), `<claims id="c">`) {
+		t.Error("cutElement modified its input despite copyFirst")
+	}
+}
+
+func TestCutElementWithoutEnd(t *testing.T) {
+	doc := []byte(`<a><description><p>Text.</p><claims><claim/></claims></a>`)
+	projected, removed := cutElement(doc, "description", true)
+	if removed || string(projected) != string(doc) {
+		t.Errorf("expected an element without its closing tag to be left in place, got %q, %v", projected, removed)
+	}
+}
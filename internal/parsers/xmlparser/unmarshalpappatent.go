@@ -0,0 +1,231 @@
+package xmlparser
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/diverged/uspt-go/internal/models"
+	"github.com/diverged/uspt-go/internal/parsers/claimtree"
+	"github.com/diverged/uspt-go/types"
+)
+
+var papInline = map[string]string{"bold": "b", "italic": "i", "underline": "u", "subscript": "sub", "superscript": "sup"}
+
+// papTextMarkup maps the subdoc-abstract and subdoc-description text elements of the pap schemas
+var papTextMarkup = legacyMarkup{
+	Headings:   map[string]bool{"heading": true},
+	Paragraphs: map[string]bool{"paragraph": true},
+	Inline:     papInline,
+	Wrappers:   map[string]string{"brief-description-of-drawings": "description-of-drawings"},
+	Skip:       map[string]bool{"number": true, "image": true},
+	LevelAttr:  "lvl",
+}
+
+// papClaimsMarkup maps the subdoc-claims of the pap schemas, where dependencies are expressed through <dependent-claim-reference depends_on="...">
+var papClaimsMarkup = legacyMarkup{
+	Headings:     map[string]bool{"heading": true},
+	Inline:       papInline,
+	Skip:         map[string]bool{"image": true},
+	LevelAttr:    "lvl",
+	Claim:        "claim",
+	ClaimText:    "claim-text",
+	ClaimRef:     "dependent-claim-reference",
+	ClaimRefAttr: "depends_on",
+}
+
+// UnmarshalPAPPatent parses a 2001-2004 pap-v15/pap-v16 <patent-application-publication> into the same types.Patent shape as the current schemas, including StructuredClaims.
+func UnmarshalPAPPatent(rawSplitDoc []byte, log types.Logger) (types.Patent, error) {
+	var patent types.Patent
+	var sawRoot bool
+
+	log.Debug("UnmarshalPAPPatent has been called")
+
+	decoder := newLenientDecoder(rawSplitDoc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Error("Error unmarshaling pap xml patent", "error", err)
+			return types.Patent{}, err
+		}
+
+		startElement, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch startElement.Name.Local {
+		case "patent-application-publication":
+			sawRoot = true
+
+		case "subdoc-bibliographic-information":
+			var biblio models.XMLPAPBibliographic
+			if err := decoder.DecodeElement(&biblio, &startElement); err != nil {
+				return types.Patent{}, err
+			}
+			mapPAPBibliographic(&patent, biblio)
+
+		case "subdoc-abstract":
+			abstract, err := papTextMarkup.walk(decoder)
+			if err != nil {
+				return types.Patent{}, err
+			}
+			patent.Abstract.Content = abstract.Content
+
+		case "subdoc-description":
+			description, err := papTextMarkup.walk(decoder)
+			if err != nil {
+				return types.Patent{}, err
+			}
+			patent.Description.Content = description.Content
+
+		case "subdoc-claims":
+			claims, err := papClaimsMarkup.walk(decoder)
+			if err != nil {
+				return types.Patent{}, err
+			}
+			claimtree.Build(claims.Claims)
+			patent.Claims.Content = claims.Content
+			patent.StructuredClaims = claims.Claims
+			patent.UsBibliographicData.NumberOfClaims = len(claims.Claims)
+		}
+	}
+
+	if !sawRoot {
+		return types.Patent{}, errors.New("pap document is missing its patent-application-publication root element")
+	}
+
+	return patent, nil
+}
+
+func mapPAPBibliographic(patent *types.Patent, biblio models.XMLPAPBibliographic) {
+	patent.MetaCountry = "US"
+	patent.MetaDatePubl = biblio.PublicationDate
+
+	bib := &patent.UsBibliographicData
+	bib.PublicationReference.DocumentID.Country = "US"
+	bib.PublicationReference.DocumentID.DocNumber = biblio.DocNumber
+	bib.PublicationReference.DocumentID.KindCode = biblio.KindCode
+	bib.PublicationReference.DocumentID.Date = biblio.PublicationDate
+
+	// publication-filing-type reads e.g. "new-utility", where the current schemas record appl-type "utility"
+	bib.ApplicationReference.ApplType = strings.TrimPrefix(biblio.FilingType, "new-")
+	bib.ApplicationReference.DocumentID.Country = "US"
+	bib.ApplicationReference.DocumentID.DocNumber = biblio.ApplNumber
+	bib.ApplicationReference.DocumentID.Date = biblio.ApplDate
+
+	bib.ClassificationNational.Country = "US"
+	bib.ClassificationNational.MainClassification = papUSPC(biblio.USMain)
+	for _, uspc := range biblio.USFurther {
+		bib.ClassificationNational.FurtherClassifications = append(bib.ClassificationNational.FurtherClassifications, papUSPC(uspc))
+	}
+	if len(bib.ClassificationNational.FurtherClassifications) > 0 {
+		bib.ClassificationNational.FurtherClassification = bib.ClassificationNational.FurtherClassifications[0]
+	}
+	bib.Classifications.IPCR = legacyIPC(biblio.IPCMain, biblio.IPCFurther)
+	for i := range bib.Classifications.IPCR {
+		bib.Classifications.IPCR[i].VersionDate = biblio.IPCEdition
+	}
+
+	for _, priority := range biblio.Priorities {
+		bib.PriorityClaims = append(bib.PriorityClaims, types.PriorityClaim{
+			Country:   priority.Country,
+			DocNumber: priority.DocNumber,
+			Date:      priority.Date,
+		})
+	}
+	for _, relations := range []struct {
+		relationType types.RelationType
+		relations    []models.XMLPAPRelation
+	}{
+		{types.RelationContinuation, biblio.Continuations},
+		{types.RelationContinuationInPart, biblio.CIPs},
+		{types.RelationDivision, biblio.Divisions},
+	} {
+		for _, relation := range relations.relations {
+			bib.RelatedDocuments = append(bib.RelatedDocuments, types.RelatedDocument{
+				Type:         relations.relationType,
+				Parent:       types.DocumentID{Country: "US", DocNumber: relation.Parent.DocNumber, Date: relation.Parent.Date},
+				ParentStatus: relation.Status,
+				ParentGrant:  papDocumentID(relation.Grant),
+				Child:        papDocumentID(relation.Child),
+			})
+		}
+	}
+	for _, provisional := range biblio.Provisionals {
+		bib.RelatedDocuments = append(bib.RelatedDocuments, types.RelatedDocument{
+			Type:   types.RelationProvisionalApplication,
+			Parent: types.DocumentID{Country: "US", DocNumber: provisional.DocNumber, Date: provisional.Date},
+		})
+	}
+
+	bib.InventionTitle.Text = legacyPlainText(biblio.Title.Inner)
+	bib.InventionTitle.Content = bib.InventionTitle.Text
+
+	inventors := biblio.Inventors
+	if biblio.FirstInventor.FamilyName != "" {
+		inventors = append([]models.XMLPAPInventor{biblio.FirstInventor}, inventors...)
+	}
+	for _, inventor := range inventors {
+		bib.Parties.Inventors = append(bib.Parties.Inventors, papInventor(inventor))
+	}
+
+	for _, assignee := range biblio.Assignees {
+		bib.Assignees = append(bib.Assignees, types.Party{
+			LastName:     assignee.FamilyName,
+			FirstName:    assignee.GivenName,
+			Organization: assignee.Organization,
+			Role:         assignee.Type,
+			Address: types.Address{
+				City:       assignee.City,
+				State:      assignee.State,
+				PostalCode: assignee.PostalCode,
+				Country:    assignee.Country,
+			},
+		})
+	}
+}
+
+func papInventor(inventor models.XMLPAPInventor) types.Party {
+	party := types.Party{
+		LastName:  strings.TrimSpace(strings.Join([]string{inventor.FamilyName, inventor.NameSuffix}, " ")),
+		FirstName: strings.TrimSpace(strings.Join([]string{inventor.GivenName, inventor.MiddleName}, " ")),
+		Address: types.Address{
+			City:    inventor.City,
+			State:   inventor.State,
+			Country: inventor.Country,
+		},
+	}
+	if inventor.ForeignCountry != "" {
+		party.Address = types.Address{
+			City:    inventor.ForeignCity,
+			State:   inventor.ForeignState,
+			Country: inventor.ForeignCountry,
+		}
+	}
+	if party.Address.Country == "" && party.Address.State != "" {
+		party.Address.Country = "US"
+	}
+	return party
+}
+
+// papUSPC formats a US classification in the current schemas' style, with the class right aligned in three characters followed by the subclass
+func papUSPC(uspc models.XMLPAPUSPC) string {
+	if uspc.Class == "" {
+		return ""
+	}
+	return fmt.Sprintf("%3s%s", uspc.Class, uspc.Subclass)
+}
+
+// papDocumentID converts an optional document of a continuity-data relation, which is always a US document
+func papDocumentID(doc *models.XMLPAPDocument) *types.DocumentID {
+	if doc == nil || doc.DocNumber == "" {
+		return nil
+	}
+	return &types.DocumentID{Country: "US", DocNumber: doc.DocNumber, Date: doc.Date}
+}
@@ -0,0 +1,104 @@
+package xmlparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+const samplePAPPatent = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE patent-application-publication SYSTEM "pap-v16-2002-01-01.dtd" [
+<!ENTITY US20020000001A1-20020103-D00000.TIF SYSTEM "US20020000001A1-20020103-D00000.TIF" NDATA TIF>
+]>
+<patent-application-publication>
+<subdoc-bibliographic-information>
+<document-id><doc-number>20020000001</doc-number><kind-code>A1</kind-code><document-date>20020103</document-date></document-id>
+<publication-filing-type>new-utility</publication-filing-type>
+<domestic-filing-data><application-number><doc-number>09725634</doc-number></application-number><application-number-series-code>09</application-number-series-code><filing-date>20001129</filing-date></domestic-filing-data>
+<continuity-data>
+<continuations><continuation-of><parent-child><child><document-id><doc-number>09725634</doc-number><kind-code>A1</kind-code><document-date>20001129</document-date></document-id></child><parent><document-id><doc-number>09300001</doc-number><document-date>19990427</document-date><country-code>US</country-code></document-id></parent><parent-status>GRANTED</parent-status><parent-patent><document-id><doc-number>6100001</doc-number><country-code>US</country-code></document-id></parent-patent></parent-child></continuation-of></continuations>
+<division-of><parent-child><child><document-id><doc-number>09300001</doc-number></document-id></child><parent><document-id><doc-number>08800001</doc-number><document-date>19970110</document-date></document-id></parent><parent-status>ABANDONED</parent-status></parent-child></division-of>
+<non-provisional-of-provisional><document-id><doc-number>60100002</doc-number><document-date>19980428</document-date></document-id></non-provisional-of-provisional>
+</continuity-data>
+<technical-information>
+<classification-us><classification-us-primary><uspc><class>172</class><subclass>677000</subclass></uspc></classification-us-primary></classification-us>
+<title-of-invention>Tillage implement</title-of-invention>
+</technical-information>
+<inventors>
+<first-named-inventor><name><given-name>Jane</given-name><middle-name>Q.</middle-name><family-name>Smith</family-name></name><residence><residence-us><city>Ames</city><state>IA</state><country-code>US</country-code></residence-us></residence></first-named-inventor>
+<inventor><name><given-name>Taro</given-name><family-name>Yamada</family-name></name><residence><residence-non-us><city>Osaka</city><country-code>JP</country-code></residence-non-us></residence></inventor>
+</inventors>
+<assignee><organization-name>Farm Tools Inc.</organization-name><assignee-type>02</assignee-type></assignee>
+</subdoc-bibliographic-information>
+<subdoc-abstract><paragraph id="A-0001" lvl="0">A tillage implement &amp; method.</paragraph></subdoc-abstract>
+<subdoc-description>
+<summary-of-invention><section><heading lvl="1">SUMMARY</heading><paragraph id="P-0001" lvl="0"><number>[0001]</number> The implement has a <bold>blade</bold>.</paragraph></section></summary-of-invention>
+</subdoc-description>
+<subdoc-claims>
+<heading lvl="1">What is claimed is:</heading>
+<claim id="CLM-00001"><claim-text>1. A tillage implement comprising: <claim-text>a frame; and</claim-text><claim-text>a blade.</claim-text></claim-text></claim>
+<claim id="CLM-00002"><claim-text>2. The implement of <dependent-claim-reference depends_on="CLM-00001"><claim-text>claim 1</claim-text></dependent-claim-reference>, wherein the blade is steel.</claim-text></claim>
+</subdoc-claims>
+</patent-application-publication>`
+
+func TestUnmarshalPAPPatent(t *testing.T) {
+	patent, err := UnmarshalPAPPatent([]byte(samplePAPPatent), &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalPAPPatent returned an error: %v", err)
+	}
+
+	biblio := patent.UsBibliographicData
+	if got := biblio.PublicationReference.DocumentID.DocNumber; got != "20020000001" {
+		t.Errorf("DocNumber = %q", got)
+	}
+	if got := biblio.ApplicationReference.ApplType; got != "utility" {
+		t.Errorf("ApplType = %q", got)
+	}
+	if got := biblio.ClassificationNational.MainClassification; got != "172677000" {
+		t.Errorf("MainClassification = %q", got)
+	}
+	if got := biblio.InventionTitle.Text; got != "Tillage implement" {
+		t.Errorf("InventionTitle = %q", got)
+	}
+	if related := biblio.RelatedDocuments; len(related) != 3 ||
+		related[0].Type != types.RelationContinuation || related[0].Parent.DocNumber != "09300001" || related[0].ParentStatus != "GRANTED" ||
+		related[0].ParentGrant == nil || related[0].ParentGrant.DocNumber != "6100001" || related[0].Child == nil || related[0].Child.DocNumber != "09725634" ||
+		related[1].Type != types.RelationDivision || related[1].Parent.Date != "19970110" || related[1].ParentGrant != nil ||
+		related[2].Type != types.RelationProvisionalApplication || related[2].Parent.DocNumber != "60100002" {
+		t.Errorf("unexpected related documents: %+v", related)
+	}
+	if len(biblio.Parties.Inventors) != 2 {
+		t.Fatalf("expected 2 inventors, got %d", len(biblio.Parties.Inventors))
+	}
+	if inv := biblio.Parties.Inventors[0]; inv.LastName != "Smith" || inv.FirstName != "Jane Q." || inv.Address.State != "IA" {
+		t.Errorf("unexpected first inventor: %+v", inv)
+	}
+	if inv := biblio.Parties.Inventors[1]; inv.Address.Country != "JP" || inv.Address.City != "Osaka" {
+		t.Errorf("unexpected second inventor: %+v", inv)
+	}
+	if len(biblio.Assignees) != 1 || biblio.Assignees[0].Organization != "Farm Tools Inc." {
+		t.Errorf("unexpected assignees: %+v", biblio.Assignees)
+	}
+
+	if !strings.Contains(patent.Abstract.Content, `A tillage implement &amp; method.`) {
+		t.Errorf("unexpected abstract: %q", patent.Abstract.Content)
+	}
+	if strings.Contains(patent.Description.Content, "[0001]") || !strings.Contains(patent.Description.Content, `<b>blade</b>`) {
+		t.Errorf("unexpected description: %q", patent.Description.Content)
+	}
+
+	claims := patent.StructuredClaims
+	if len(claims) != 2 || biblio.NumberOfClaims != 2 {
+		t.Fatalf("expected 2 claims, got %d", len(claims))
+	}
+	if claims[0].Type != "INDEPENDENT" || len(claims[0].Text) != 3 || claims[0].Text[0] != "1. A tillage implement comprising:" {
+		t.Errorf("unexpected claim 1: %+v", claims[0])
+	}
+	if claims[1].Type != "DEPENDENT" || claims[1].ClaimTree.ParentIds[0] != "CLM-00001" || len(claims[1].Text) != 1 {
+		t.Errorf("unexpected claim 2: %+v", claims[1])
+	}
+	if claims[0].ClaimTree.ChildCount != 1 || claims[1].ClaimTree.ClaimTreeLevel != 1 {
+		t.Errorf("claim tree not linked: %+v %+v", claims[0].ClaimTree, claims[1].ClaimTree)
+	}
+}
@@ -0,0 +1,238 @@
+package xmlparser
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+
+	"github.com/diverged/uspt-go/internal/models"
+	"github.com/diverged/uspt-go/internal/parsers/claimtree"
+	"github.com/diverged/uspt-go/types"
+)
+
+// v25TextMarkup maps the SDOAB and SDODE text elements of the v2.5 grant format
+var v25TextMarkup = legacyMarkup{
+	Headings:   map[string]bool{"H": true},
+	Paragraphs: map[string]bool{"PARA": true},
+	Inline:     map[string]string{"BOLD": "b", "ITALIC": "i", "U": "u", "SB": "sub", "SP": "sup"},
+	Wrappers:   map[string]string{"DRWDESC": "description-of-drawings"},
+	Skip:       map[string]bool{"EMI": true, "CWU": true},
+	LevelAttr:  "LVL",
+}
+
+// v25ClaimsMarkup maps the SDOCL claims of the v2.5 grant format
+var v25ClaimsMarkup = legacyMarkup{
+	Headings:     map[string]bool{"H": true},
+	Inline:       map[string]string{"BOLD": "b", "ITALIC": "i", "U": "u", "SB": "sub", "SP": "sup"},
+	Skip:         map[string]bool{"EMI": true, "CWU": true},
+	LevelAttr:    "LVL",
+	Claim:        "CLM",
+	ClaimText:    "CLMSTEP",
+	ClaimRef:     "CLREF",
+	ClaimRefAttr: "ID",
+}
+
+// UnmarshalV25Patent parses a 2001-2004 v2.5 <PATDOC> grant into the same types.Patent shape as the current schemas, including StructuredClaims.
+func UnmarshalV25Patent(rawSplitDoc []byte, log types.Logger) (types.Patent, error) {
+	var patent types.Patent
+	var sawRoot bool
+
+	log.Debug("UnmarshalV25Patent has been called")
+
+	decoder := newLenientDecoder(rawSplitDoc)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Error("Error unmarshaling v2.5 xml patent", "error", err)
+			return types.Patent{}, err
+		}
+
+		startElement, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch startElement.Name.Local {
+		case "PATDOC":
+			sawRoot = true
+			patent.MetaDtdVersion = legacyAttr(startElement, "DTD")
+			patent.MetaStatus = legacyAttr(startElement, "STATUS")
+
+		case "SDOBI":
+			var biblio models.XMLV25Bibliographic
+			if err := decoder.DecodeElement(&biblio, &startElement); err != nil {
+				return types.Patent{}, err
+			}
+			mapV25Bibliographic(&patent, biblio)
+
+		case "SDOAB":
+			abstract, err := v25TextMarkup.walk(decoder)
+			if err != nil {
+				return types.Patent{}, err
+			}
+			patent.Abstract.Content = abstract.Content
+
+		case "SDODE":
+			description, err := v25TextMarkup.walk(decoder)
+			if err != nil {
+				return types.Patent{}, err
+			}
+			patent.Description.Content = description.Content
+
+		case "SDOCL":
+			claims, err := v25ClaimsMarkup.walk(decoder)
+			if err != nil {
+				return types.Patent{}, err
+			}
+			claimtree.Build(claims.Claims)
+			patent.Claims.Content = claims.Content
+			patent.StructuredClaims = claims.Claims
+		}
+	}
+
+	if !sawRoot {
+		return types.Patent{}, errors.New("v2.5 grant document is missing its PATDOC root element")
+	}
+
+	return patent, nil
+}
+
+func mapV25Bibliographic(patent *types.Patent, biblio models.XMLV25Bibliographic) {
+	country := biblio.Country
+	if country == "" {
+		country = "US"
+	}
+	patent.MetaCountry = country
+	patent.MetaDatePubl = biblio.PublicationDate
+
+	bib := &patent.UsBibliographicData
+	bib.PublicationReference.DocumentID.Country = country
+	bib.PublicationReference.DocumentID.DocNumber = biblio.DocNumber
+	bib.PublicationReference.DocumentID.KindCode = biblio.KindCode
+	bib.PublicationReference.DocumentID.Date = biblio.PublicationDate
+
+	bib.ApplicationReference.ApplType = biblio.ApplType
+	bib.ApplicationReference.DocumentID.Country = country
+	bib.ApplicationReference.DocumentID.DocNumber = biblio.ApplNumber
+	bib.ApplicationReference.DocumentID.Date = biblio.ApplDate
+
+	bib.ClassificationNational.Country = "US"
+	bib.ClassificationNational.MainClassification = biblio.USMain
+	bib.ClassificationNational.FurtherClassifications = biblio.USFurther
+	if len(biblio.USFurther) > 0 {
+		bib.ClassificationNational.FurtherClassification = biblio.USFurther[0]
+	}
+	bib.Classifications.IPCR = legacyIPC(biblio.IPCMain, biblio.IPCFurther)
+
+	for _, priority := range biblio.Priorities {
+		bib.PriorityClaims = append(bib.PriorityClaims, types.PriorityClaim{
+			Country:   priority.Country,
+			DocNumber: priority.DocNumber,
+			Date:      priority.Date,
+		})
+	}
+	for _, relations := range []struct {
+		relationType types.RelationType
+		parents      []models.XMLV25Parent
+	}{
+		{types.RelationContinuation, biblio.Continuations},
+		{types.RelationContinuationInPart, biblio.CIPs},
+		{types.RelationDivision, biblio.Divisions},
+		{types.RelationReissue, biblio.Reissues},
+		{types.RelationSubstitution, biblio.Substitutions},
+	} {
+		for _, parent := range relations.parents {
+			bib.RelatedDocuments = append(bib.RelatedDocuments, types.RelatedDocument{
+				Type:         relations.relationType,
+				Parent:       types.DocumentID{Country: "US", DocNumber: parent.Parent.DocNumber, Date: parent.Parent.Date},
+				ParentStatus: parent.Status,
+				ParentGrant:  v25DocumentID(parent.Grant),
+				Child:        v25DocumentID(parent.Child),
+			})
+		}
+	}
+	for _, provisional := range biblio.Provisionals {
+		bib.RelatedDocuments = append(bib.RelatedDocuments, types.RelatedDocument{
+			Type:   types.RelationProvisionalApplication,
+			Parent: types.DocumentID{Country: "US", DocNumber: provisional.DocNumber, Date: provisional.Date},
+		})
+	}
+
+	bib.InventionTitle.Text = legacyPlainText(biblio.Title.Inner)
+	bib.InventionTitle.Content = bib.InventionTitle.Text
+	bib.NumberOfClaims = biblio.NumberOfClaims
+
+	for _, inventor := range biblio.Inventors {
+		bib.Parties.Inventors = append(bib.Parties.Inventors, v25Party(inventor))
+	}
+	for _, agent := range biblio.Agents {
+		bib.Parties.Agents = append(bib.Parties.Agents, v25Party(agent))
+	}
+	for _, assignee := range biblio.Assignees {
+		party := v25Party(assignee.Party)
+		party.Role = assignee.Role
+		bib.Assignees = append(bib.Assignees, party)
+	}
+
+	for _, citation := range biblio.PatentCitations {
+		citationCountry := citation.Country
+		if citationCountry == "" {
+			citationCountry = "US"
+		}
+		patent.Citations.Patent = append(patent.Citations.Patent, types.PatentCitation{
+			Country:   citationCountry,
+			DocNumber: citation.DocNumber,
+			Kind:      citation.KindCode,
+			Name:      citation.Name,
+			Date:      citation.Date,
+			Category:  v25CitedBy(citation.XMLV25CitedBy),
+		})
+	}
+	for _, citation := range biblio.OtherCitations {
+		patent.Citations.NonPatent = append(patent.Citations.NonPatent, types.NonPatentCitation{
+			Text:     legacyPlainText(citation.Text.Inner),
+			Category: v25CitedBy(citation.XMLV25CitedBy),
+		})
+	}
+}
+
+// v25CitedBy maps the CITED-BY-EXAMINER and CITED-BY-OTHER markers onto the category text of the current schemas
+func v25CitedBy(citedBy models.XMLV25CitedBy) string {
+	switch {
+	case citedBy.Examiner != nil:
+		return types.CitedByExaminer
+	case citedBy.Other != nil:
+		return types.CitedByOther
+	}
+	return ""
+}
+
+func v25Party(party models.XMLV25Party) types.Party {
+	country := party.Country
+	if country == "" && party.State != "" {
+		country = "US"
+	}
+	return types.Party{
+		LastName:     party.LastName,
+		FirstName:    party.FirstName,
+		Organization: party.Organization,
+		Address: types.Address{
+			Street:     party.Street,
+			City:       party.City,
+			State:      party.State,
+			PostalCode: party.PostalCode,
+			Country:    country,
+		},
+	}
+}
+
+// v25DocumentID converts an optional DOC of a B600 related document, which is always a US document
+func v25DocumentID(doc *models.XMLV25Doc) *types.DocumentID {
+	if doc == nil || doc.DocNumber == "" {
+		return nil
+	}
+	return &types.DocumentID{Country: "US", DocNumber: doc.DocNumber, Date: doc.Date}
+}
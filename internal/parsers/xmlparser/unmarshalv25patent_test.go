@@ -0,0 +1,111 @@
+package xmlparser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+const sampleV25Patent = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE PATDOC SYSTEM "ST32-US-Grant-025xml.dtd" [
+<!ENTITY US06334220-20020101-D00000.TIF SYSTEM "US06334220-20020101-D00000.TIF" NDATA TIF>
+]>
+<PATDOC DTD="2.5" STATUS="Build 20011220">
+<SDOBI>
+<B100><B110><DNUM><PDAT>06334220</PDAT></DNUM></B110><B130><PDAT>B1</PDAT></B130><B140><DATE><PDAT>20020101</PDAT></DATE></B140><B190><PDAT>US</PDAT></B190></B100>
+<B200><B210><DNUM><PDAT>09475512</PDAT></DNUM></B210><B211US><PDAT>utility</PDAT></B211US><B220><DATE><PDAT>19991230</PDAT></DATE></B220></B200>
+<B500>
+<B520><B521><PDAT>606232</PDAT></B521><B522><PDAT>606 60</PDAT></B522></B520>
+<B540><STEXT><PDAT>Surgical </PDAT><ITALIC><PDAT>clip</PDAT></ITALIC><PDAT> applier</PDAT></STEXT></B540>
+<B560><B561><PCIT><DOC><DNUM><PDAT>4790813</PDAT></DNUM><DATE><PDAT>19881200</PDAT></DATE><KIND><PDAT>A</PDAT></KIND></DOC><PARTY-US><NAM><SNM><STEXT><PDAT>Kensey</PDAT></STEXT></SNM></NAM></PARTY-US></PCIT><CITED-BY-EXAMINER/></B561><B562><NCIT><STEXT><PDAT>Doe, Clip Design, 1998.</PDAT></STEXT></NCIT><CITED-BY-OTHER/></B562></B560>
+<B570><B577><PDAT>2</PDAT></B577></B570>
+</B500>
+<B600><B620><PARENT-US><CDOC><DOC><DNUM><PDAT>09475512</PDAT></DNUM><DATE><PDAT>19991230</PDAT></DATE></DOC></CDOC><PDOC><DOC><DNUM><PDAT>09012345</PDAT></DNUM><DATE><PDAT>19980115</PDAT></DATE></DOC></PDOC><PSTA><PDAT>GRANTED</PDAT></PSTA><PPUB><DOC><DNUM><PDAT>6000001</PDAT></DNUM><DATE><PDAT>19991207</PDAT></DATE></DOC></PPUB></PARENT-US></B620>
+<B630><B632><PARENT-US><CDOC><DOC><DNUM><PDAT>09475512</PDAT></DNUM></DOC></CDOC><PDOC><DOC><DNUM><PDAT>08900001</PDAT></DNUM><DATE><PDAT>19970601</PDAT></DATE></DOC></PDOC><PSTA><PDAT>ABANDONED</PDAT></PSTA></PARENT-US></B632></B630>
+<B680US><DOC><DNUM><PDAT>60100001</PDAT></DNUM><DATE><PDAT>19981231</PDAT></DATE></DOC></B680US></B600>
+<B700>
+<B720><B721><PARTY-US><NAM><FNM><PDAT>John</PDAT></FNM><SNM><STEXT><PDAT>Doe</PDAT></STEXT></SNM></NAM><ADR><CITY><PDAT>Boston</PDAT></CITY><STATE><PDAT>MA</PDAT></STATE></ADR></PARTY-US></B721></B720>
+<B730><B731><PARTY-US><NAM><ONM><STEXT><PDAT>Acme Medical</PDAT></STEXT></ONM></NAM></PARTY-US></B731><B732US><PDAT>02</PDAT></B732US></B730>
+</B700>
+</SDOBI>
+<SDOAB><BTEXT><PARA ID="P-00001" LVL="0"><PTEXT><PDAT>A clip applier &amp; method.</PDAT></PTEXT></PARA></BTEXT></SDOAB>
+<SDODE>
+<BRFSUM><H LVL="1"><STEXT><PDAT>BACKGROUND</PDAT></STEXT></H><PARA ID="P-00002" LVL="0"><PTEXT><PDAT>H</PDAT><SB><PDAT>2</PDAT></SB><PDAT>O based.</PDAT></PTEXT></PARA></BRFSUM>
+<DRWDESC><PARA ID="P-00003" LVL="0"><PTEXT><PDAT>FIG. 1 is a view.</PDAT></PTEXT></PARA></DRWDESC>
+</SDODE>
+<SDOCL><H LVL="1"><STEXT><PDAT>What is claimed is:</PDAT></STEXT></H>
+<CL>
+<CLM ID="CLM-00001"><PARA ID="P-00010" LVL="0"><PTEXT><PDAT>1. A clip applier comprising:</PDAT></PTEXT></PARA><CLMSTEP LVL="1"><PTEXT><PDAT>a jaw.</PDAT></PTEXT></CLMSTEP></CLM>
+<CLM ID="CLM-00002"><PARA ID="P-00011" LVL="0"><PTEXT><PDAT>2. The clip applier of claim </PDAT><CLREF ID="CLM-00001"><PDAT>1</PDAT></CLREF><PDAT>, wherein the jaw is curved.</PDAT></PTEXT></PARA></CLM>
+</CL>
+</SDOCL>
+</PATDOC>`
+
+func TestUnmarshalV25Patent(t *testing.T) {
+	patent, err := UnmarshalV25Patent([]byte(sampleV25Patent), &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalV25Patent returned an error: %v", err)
+	}
+
+	biblio := patent.UsBibliographicData
+	if got := biblio.PublicationReference.DocumentID.DocNumber; got != "06334220" {
+		t.Errorf("DocNumber = %q", got)
+	}
+	if got := biblio.PublicationReference.DocumentID.KindCode; got != "B1" {
+		t.Errorf("KindCode = %q", got)
+	}
+	if got := biblio.ApplicationReference.DocumentID.Date; got != "19991230" {
+		t.Errorf("application Date = %q", got)
+	}
+	if got := biblio.InventionTitle.Text; got != "Surgical clip applier" {
+		t.Errorf("InventionTitle = %q", got)
+	}
+	if patent.MetaDtdVersion != "2.5" || patent.MetaDatePubl != "20020101" {
+		t.Errorf("unexpected metadata: dtd %q, date %q", patent.MetaDtdVersion, patent.MetaDatePubl)
+	}
+	if biblio.NumberOfClaims != 2 || biblio.ClassificationNational.MainClassification != "606232" {
+		t.Errorf("unexpected claims count or classification: %d, %q", biblio.NumberOfClaims, biblio.ClassificationNational.MainClassification)
+	}
+	if len(biblio.Parties.Inventors) != 1 || biblio.Parties.Inventors[0].LastName != "Doe" || biblio.Parties.Inventors[0].Address.City != "Boston" {
+		t.Errorf("unexpected inventors: %+v", biblio.Parties.Inventors)
+	}
+	if len(biblio.Assignees) != 1 || biblio.Assignees[0].Organization != "Acme Medical" || biblio.Assignees[0].Role != "02" {
+		t.Errorf("unexpected assignees: %+v", biblio.Assignees)
+	}
+	if related := biblio.RelatedDocuments; len(related) != 3 ||
+		related[0].Type != types.RelationContinuationInPart || related[0].Parent.DocNumber != "08900001" || related[0].ParentStatus != "ABANDONED" || related[0].ParentGrant != nil ||
+		related[1].Type != types.RelationDivision || related[1].ParentGrant == nil || related[1].ParentGrant.DocNumber != "6000001" || related[1].Child == nil || related[1].Child.DocNumber != "09475512" ||
+		related[2].Type != types.RelationProvisionalApplication || related[2].Parent.DocNumber != "60100001" {
+		t.Errorf("unexpected related documents: %+v", related)
+	}
+	if len(patent.Citations.Patent) != 1 || patent.Citations.Patent[0].Name != "Kensey" || patent.Citations.Patent[0].Category != types.CitedByExaminer {
+		t.Errorf("unexpected citations: %+v", patent.Citations.Patent)
+	}
+	if len(patent.Citations.NonPatent) != 1 || patent.Citations.NonPatent[0].Text != "Doe, Clip Design, 1998." || patent.Citations.NonPatent[0].Category != types.CitedByOther {
+		t.Errorf("unexpected non-patent citations: %+v", patent.Citations.NonPatent)
+	}
+
+	if !strings.Contains(patent.Abstract.Content, `<p id="p-00001" num="00001">A clip applier &amp; method.</p>`) {
+		t.Errorf("unexpected abstract: %q", patent.Abstract.Content)
+	}
+	for _, want := range []string{`<heading level="1">BACKGROUND</heading>`, `H<sub>2</sub>O based.`, `<description-of-drawings><p id="p-00003"`} {
+		if !strings.Contains(patent.Description.Content, want) {
+			t.Errorf("description missing %q: %q", want, patent.Description.Content)
+		}
+	}
+
+	claims := patent.StructuredClaims
+	if len(claims) != 2 {
+		t.Fatalf("expected 2 claims, got %d", len(claims))
+	}
+	if claims[0].Type != "INDEPENDENT" || len(claims[0].Text) != 2 || claims[0].Text[1] != "a jaw." || claims[0].ClaimTree.ChildCount != 1 {
+		t.Errorf("unexpected claim 1: %+v", claims[0])
+	}
+	if claims[1].Type != "DEPENDENT" || claims[1].ClaimTree.ParentIds[0] != "CLM-00001" || claims[1].Text[0] != "2. The clip applier of claim 1, wherein the jaw is curved." {
+		t.Errorf("unexpected claim 2: %+v", claims[1])
+	}
+	if !strings.Contains(patent.Claims.Content, `<claim-ref idref="CLM-00001">1</claim-ref>`) {
+		t.Errorf("claims content missing claim-ref: %q", patent.Claims.Content)
+	}
+}
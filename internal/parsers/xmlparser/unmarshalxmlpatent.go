@@ -3,32 +3,353 @@ package xmlparser
 import (
 	"encoding/xml"
 	"errors"
+	"strconv"
+	"strings"
 
+	"github.com/diverged/uspt-go/internal/models"
 	"github.com/diverged/uspt-go/types"
 )
 
+// xmlPatentDocument wraps types.Patent to capture the bibliographic section, whose element name differs between grants and applications
+type xmlPatentDocument struct {
+	types.Patent
+	BibliographicDataGrant       *xmlBibliographicData `xml:"us-bibliographic-data-grant"`
+	BibliographicDataApplication *xmlBibliographicData `xml:"us-bibliographic-data-application"`
+}
+
+// xmlBibliographicData pairs the directly mapped types.UsBibliographicData fields with the raw elements that are normalized after unmarshaling
+type xmlBibliographicData struct {
+	types.UsBibliographicData
+	models.XMLBibliographicParties
+	models.XMLBibliographicClassifications
+	models.XMLBibliographicCitations
+	models.XMLBibliographicRelations
+	models.XMLBibliographicProsecution
+}
+
 func UnmarshalXmlPatent(rawSplitDoc []byte, patentDocType string, errChan chan<- error, log types.Logger) (types.Patent, error) {
-	var patent types.Patent
+	var document xmlPatentDocument
 
 	log.Debug("UnmarshalXmlPatent has been called")
 
 	switch patentDocType {
-	case "application":
-		patent.XMLName = xml.Name{Local: "us-patent-application"}
-		patent.UsBibliographicData.XMLName = xml.Name{Local: "us-bibliographic-data-application"}
-	case "grant":
-		patent.XMLName = xml.Name{Local: "us-patent-grant"}
-		patent.UsBibliographicData.XMLName = xml.Name{Local: "us-bibliographic-data-grant"}
+	case "application", "grant":
 	default:
 		log.Error("Unknown document type: %s", patentDocType)
 		err := errors.New("unknown document type when attempting to unmarshal xml patent")
 		return types.Patent{}, err
 	}
 
-	if err := xml.Unmarshal(rawSplitDoc, &patent); err != nil {
+	if err := xml.Unmarshal(rawSplitDoc, &document); err != nil {
 		log.Error("Error unmarshaling xml patent", "error", err)
 		return types.Patent{}, err
 	}
 
+	patent := document.Patent
+	biblio := document.BibliographicDataGrant
+
+	switch patentDocType {
+	case "application":
+		patent.XMLName = xml.Name{Local: "us-patent-application"}
+		biblio = document.BibliographicDataApplication
+	case "grant":
+		patent.XMLName = xml.Name{Local: "us-patent-grant"}
+	}
+
+	if biblio == nil {
+		log.Warn("XML patent has no bibliographic data for its document type", "type", patentDocType)
+		return patent, nil
+	}
+
+	patent.UsBibliographicData = biblio.UsBibliographicData
+	patent.UsBibliographicData.XMLName = xml.Name{Local: "us-bibliographic-data-" + patentDocType}
+	patent.UsBibliographicData.Parties, patent.UsBibliographicData.Assignees = mapXMLParties(biblio.XMLBibliographicParties)
+	patent.UsBibliographicData.Classifications = mapXMLClassifications(biblio.XMLBibliographicClassifications)
+	if further := patent.UsBibliographicData.ClassificationNational.FurtherClassifications; len(further) > 0 {
+		patent.UsBibliographicData.ClassificationNational.FurtherClassification = further[0]
+	}
+	patent.UsBibliographicData.RelatedDocuments, patent.UsBibliographicData.PriorityClaims = mapXMLRelations(biblio.XMLBibliographicRelations)
+	mapXMLProsecution(&patent.UsBibliographicData, biblio.XMLBibliographicProsecution, log)
+	patent.Citations = mapXMLCitations(biblio.XMLBibliographicCitations)
+
 	return patent, nil
 }
+
+// mapXMLParties normalizes the v40-v47 party elements.
+// Before v43, inventors are only listed as applicants with app-type "applicant-inventor", so they are copied into Inventors when no <inventors> list exists.
+func mapXMLParties(raw models.XMLBibliographicParties) (types.Parties, []types.Party) {
+	var parties types.Parties
+
+	for _, source := range []models.XMLParties{raw.UsParties, raw.Parties} {
+		for _, applicant := range append(source.UsApplicants, source.Applicants...) {
+			party := xmlAddressbookParty(applicant.Addressbook)
+			party.Sequence = applicant.Sequence
+			party.Role = applicant.AppType
+			if party.Role == "" {
+				party.Role = applicant.Category
+			}
+			party.Residence = applicant.Residence
+			party.Nationality = applicant.Nationality
+			parties.Applicants = append(parties.Applicants, party)
+		}
+		for _, inventor := range source.Inventors {
+			party := xmlAddressbookParty(inventor.Addressbook)
+			party.Sequence = inventor.Sequence
+			parties.Inventors = append(parties.Inventors, party)
+		}
+		for _, agent := range source.Agents {
+			party := xmlAddressbookParty(agent.Addressbook)
+			party.Sequence = agent.Sequence
+			party.Role = agent.RepType
+			parties.Agents = append(parties.Agents, party)
+		}
+	}
+
+	if len(parties.Inventors) == 0 {
+		for _, applicant := range parties.Applicants {
+			if applicant.Role == "applicant-inventor" || applicant.Role == "inventor" {
+				inventor := applicant
+				inventor.Role = ""
+				parties.Inventors = append(parties.Inventors, inventor)
+			}
+		}
+	}
+
+	var assignees []types.Party
+	for _, assignee := range raw.Assignees {
+		party := xmlAddressbookParty(assignee.Addressbook)
+		if party.Organization == "" {
+			party.Organization = assignee.Orgname
+		}
+		if party.Role == "" {
+			party.Role = assignee.Role
+		}
+		assignees = append(assignees, party)
+	}
+
+	return parties, assignees
+}
+
+func xmlAddressbookParty(addressbook models.XMLAddressbook) types.Party {
+	street := addressbook.Street
+	if street == "" {
+		street = addressbook.Address1
+	}
+	return types.Party{
+		LastName:     strings.TrimSpace(strings.Join([]string{addressbook.LastName, addressbook.Suffix}, " ")),
+		FirstName:    strings.TrimSpace(strings.Join([]string{addressbook.FirstName, addressbook.MiddleName}, " ")),
+		Organization: addressbook.Orgname,
+		Role:         addressbook.Role,
+		Address: types.Address{
+			Street:     street,
+			City:       addressbook.City,
+			State:      addressbook.State,
+			PostalCode: addressbook.Postcode,
+			Country:    addressbook.Country,
+		},
+	}
+}
+
+// mapXMLClassifications structures the IPC and CPC symbols, listing the main CPC classifications ahead of the further ones
+func mapXMLClassifications(raw models.XMLBibliographicClassifications) types.Classifications {
+	var classifications types.Classifications
+
+	for _, ipcr := range raw.IPCR {
+		classification := xmlClassification(ipcr, ipcr.IPCVersionDate)
+		classification.Main = ipcr.SymbolPosition == "F"
+		classifications.IPCR = append(classifications.IPCR, classification)
+	}
+	for _, cpc := range raw.MainCPC {
+		classification := xmlClassification(cpc, cpc.CPCVersionDate)
+		classification.Main = true
+		classifications.CPC = append(classifications.CPC, classification)
+	}
+	for _, cpc := range raw.FurtherCPC {
+		classifications.CPC = append(classifications.CPC, xmlClassification(cpc, cpc.CPCVersionDate))
+	}
+
+	if raw.Locarno != nil {
+		classifications.Locarno = &types.LocarnoClassification{
+			Edition:            strings.TrimSpace(raw.Locarno.Edition),
+			MainClassification: strings.TrimSpace(raw.Locarno.MainClassification),
+		}
+	}
+
+	return classifications
+}
+
+// mapXMLCitations splits the cited references into patent and non-patent citations, keeping the party that cited each one
+func mapXMLCitations(raw models.XMLBibliographicCitations) types.Citations {
+	var citations types.Citations
+
+	for _, citation := range append(raw.ReferencesCited, raw.UsReferencesCited...) {
+		category := strings.TrimSpace(citation.Category)
+		switch {
+		case citation.PatCit != nil:
+			patcit := citation.PatCit
+			citations.Patent = append(citations.Patent, types.PatentCitation{
+				Sequence:  patcit.Num,
+				Country:   patcit.Country,
+				DocNumber: patcit.DocNumber,
+				Kind:      patcit.Kind,
+				Name:      patcit.Name,
+				Date:      patcit.Date,
+				Category:  category,
+			})
+		case citation.NplCit != nil:
+			citations.NonPatent = append(citations.NonPatent, types.NonPatentCitation{
+				Sequence: citation.NplCit.Num,
+				Text:     legacyPlainText(citation.NplCit.Othercit.Inner),
+				Category: category,
+			})
+		}
+	}
+
+	return citations
+}
+
+// mapXMLRelations flattens us-related-documents into typed relations, and copies the priority claims
+func mapXMLRelations(raw models.XMLBibliographicRelations) ([]types.RelatedDocument, []types.PriorityClaim) {
+	var related []types.RelatedDocument
+
+	for _, entry := range append(raw.UsRelated.Entries, raw.Related.Entries...) {
+		relationType := types.RelationType(entry.XMLName.Local)
+		for _, relation := range entry.Relations {
+			if relation.ParentDocumentID == nil {
+				continue
+			}
+			related = append(related, types.RelatedDocument{
+				Type:         relationType,
+				Parent:       *xmlDocumentID(relation.ParentDocumentID),
+				ParentStatus: strings.TrimSpace(relation.ParentStatus),
+				ParentGrant:  xmlDocumentID(relation.ParentGrant),
+				ParentPCT:    xmlDocumentID(relation.ParentPCT),
+				Child:        xmlDocumentID(relation.Child),
+			})
+		}
+		if len(entry.Relations) == 0 && entry.DocumentID != nil {
+			related = append(related, types.RelatedDocument{
+				Type:   relationType,
+				Parent: *xmlDocumentID(entry.DocumentID),
+			})
+		}
+	}
+
+	var priorityClaims []types.PriorityClaim
+	for _, claim := range raw.Priorities {
+		priorityClaims = append(priorityClaims, types.PriorityClaim{
+			Sequence:  claim.Sequence,
+			Kind:      claim.Kind,
+			Country:   claim.Country,
+			DocNumber: claim.DocNumber,
+			Date:      claim.Date,
+		})
+	}
+
+	return related, priorityClaims
+}
+
+// mapXMLProsecution copies the term of grant, examiners, PCT data and field of search, leaving each nil when the element is absent
+func mapXMLProsecution(biblio *types.UsBibliographicData, raw models.XMLBibliographicProsecution, log types.Logger) {
+	if term := raw.TermOfGrant; term != nil {
+		biblio.TermOfGrant = &types.TermOfGrant{
+			ExtensionDays: xmlInt(term.Extension, "us-term-extension", log),
+			LengthOfGrant: xmlInt(term.LengthOfGrant, "length-of-grant", log),
+			Disclaimer:    legacyPlainText(term.Disclaimer.Inner),
+		}
+	}
+
+	if examiners := raw.Examiners; examiners != nil {
+		biblio.Examiners = &types.Examiners{
+			Primary:   xmlExaminer(examiners.Primary),
+			Assistant: xmlExaminer(examiners.Assistant),
+		}
+	}
+
+	if pct := raw.PCTFiling; pct != nil {
+		date371 := pct.Date371c124
+		if date371 == "" {
+			date371 = pct.Date371c12
+		}
+		biblio.PCTFiling = &types.PCTFiling{
+			DocumentID: *xmlDocumentID(&pct.DocumentID),
+			Date371:    date371,
+		}
+	}
+	biblio.PCTPublication = xmlDocumentID(raw.PCTPublication)
+
+	// v4.0 and v4.1 name the element field-of-search
+	search := raw.UsFieldSearch
+	if search == nil {
+		search = raw.FieldSearch
+	}
+	if search != nil {
+		biblio.FieldOfSearch = &types.FieldOfSearch{}
+		for _, national := range search.National {
+			biblio.FieldOfSearch.National = append(biblio.FieldOfSearch.National, types.SearchClassification{
+				Country:            national.Country,
+				MainClassification: strings.TrimSpace(national.MainClassification),
+				AdditionalInfo:     national.AdditionalInfo,
+			})
+		}
+		for _, cpc := range search.CPCText {
+			biblio.FieldOfSearch.CPC = append(biblio.FieldOfSearch.CPC, strings.TrimSpace(cpc))
+		}
+	}
+}
+
+func xmlExaminer(raw *models.XMLExaminer) *types.Examiner {
+	if raw == nil {
+		return nil
+	}
+	return &types.Examiner{
+		LastName:   raw.LastName,
+		FirstName:  raw.FirstName,
+		Department: raw.Department,
+	}
+}
+
+// xmlInt parses an optional numeric element, logging rather than failing on a malformed value
+func xmlInt(value, element string, log types.Logger) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Warn("XML patent has a non-numeric element", "element", element, "value", value)
+	}
+	return n
+}
+
+func xmlDocumentID(raw *models.XMLDocumentID) *types.DocumentID {
+	if raw == nil {
+		return nil
+	}
+	return &types.DocumentID{
+		Country:   raw.Country,
+		DocNumber: raw.DocNumber,
+		Kind:      raw.Kind,
+		Date:      raw.Date,
+	}
+}
+
+func xmlClassification(raw models.XMLClassification, versionDate string) types.Classification {
+	return types.Classification{
+		Symbol:                types.FormatClassificationSymbol(raw.Section, raw.Class, raw.Subclass, raw.MainGroup, raw.Subgroup),
+		Section:               strings.TrimSpace(raw.Section),
+		Class:                 strings.TrimSpace(raw.Class),
+		Subclass:              strings.TrimSpace(raw.Subclass),
+		MainGroup:             strings.TrimSpace(raw.MainGroup),
+		Subgroup:              strings.TrimSpace(raw.Subgroup),
+		Value:                 raw.Value,
+		VersionDate:           versionDate,
+		Level:                 raw.Level,
+		SymbolPosition:        raw.SymbolPosition,
+		ActionDate:            raw.ActionDate,
+		GeneratingOffice:      raw.GeneratingOffice,
+		Status:                raw.Status,
+		DataSource:            raw.DataSource,
+		SchemeOriginationCode: raw.SchemeOriginationCode,
+	}
+}
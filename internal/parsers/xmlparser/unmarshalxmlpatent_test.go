@@ -0,0 +1,276 @@
+package xmlparser
+
+import (
+	"testing"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+const sampleGrantV45 = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE us-patent-grant SYSTEM "us-patent-grant-v45-2014-04-03.dtd" [ ]>
+<us-patent-grant lang="EN" dtd-version="v4.5 2014-04-03" file="US10000001-20180619.XML" status="PRODUCTION" id="us-patent-grant" country="US" date-produced="20180605" date-publ="20180619">
+<us-bibliographic-data-grant>
+<publication-reference><document-id><country>US</country><doc-number>10000001</doc-number><kind>B2</kind><date>20180619</date></document-id></publication-reference>
+<application-reference appl-type="utility"><document-id><country>US</country><doc-number>14643719</doc-number><date>20150310</date></document-id></application-reference>
+<us-term-of-grant><us-term-extension>312</us-term-extension><disclaimer><text>This patent is subject to a terminal disclaimer.</text></disclaimer></us-term-of-grant>
+<priority-claims>
+<priority-claim sequence="01" kind="national"><country>JP</country><doc-number>2014-052107</doc-number><date>20140314</date></priority-claim>
+</priority-claims>
+<classifications-ipcr>
+<classification-ipcr><ipc-version-indicator><date>20060101</date></ipc-version-indicator><classification-level>A</classification-level><section>B</section><class>29</class><subclass>C</subclass><main-group>45</main-group><subgroup>17</subgroup><symbol-position>F</symbol-position><classification-value>I</classification-value><action-date><date>20180619</date></action-date><generating-office><country>US</country></generating-office><classification-status>B</classification-status><classification-data-source>H</classification-data-source></classification-ipcr>
+</classifications-ipcr>
+<classifications-cpc>
+<main-cpc><classification-cpc><cpc-version-indicator><date>20130101</date></cpc-version-indicator><section>B</section><class>29</class><subclass>C</subclass><main-group>45</main-group><subgroup>1775</subgroup><symbol-position>F</symbol-position><classification-value>I</classification-value><scheme-origination-code>C</scheme-origination-code></classification-cpc></main-cpc>
+<further-cpc><classification-cpc><cpc-version-indicator><date>20130101</date></cpc-version-indicator><section>B</section><class>29</class><subclass>C</subclass><main-group>2045</main-group><subgroup>1784</subgroup><symbol-position>L</symbol-position><classification-value>A</classification-value></classification-cpc></further-cpc>
+</classifications-cpc>
+<classification-national><country>US</country><main-classification>425542</main-classification><further-classification>425588</further-classification><further-classification>264328.1</further-classification></classification-national>
+<invention-title id="d2e43">Injection molding machine</invention-title>
+<us-references-cited>
+<us-citation><patcit num="00001"><document-id><country>US</country><doc-number>4828475</doc-number><kind>A</kind><name>Ueno et al.</name><date>19890500</date></document-id></patcit><category>cited by examiner</category><classification-national><country>US</country><main-classification>425145</main-classification></classification-national></us-citation>
+<us-citation><patcit num="00002"><document-id><country>JP</country><doc-number>2005-231065</doc-number><kind>A</kind><date>20050900</date></document-id></patcit><category>cited by applicant</category></us-citation>
+<us-citation><nplcit num="00003"><othercit>Smith, &#x201c;Molding <i>in situ</i>,&#x201d; J. Polym. Sci., 2010.</othercit></nplcit><category>cited by applicant</category></us-citation>
+</us-references-cited>
+<number-of-claims>2</number-of-claims>
+<us-related-documents>
+<continuation-in-part><relation><parent-doc><document-id><country>US</country><doc-number>13000001</doc-number><date>20110105</date></document-id><parent-status>GRANTED</parent-status><parent-grant-document><document-id><country>US</country><doc-number>8900000</doc-number><kind>B2</kind></document-id></parent-grant-document></parent-doc><child-doc><document-id><country>US</country><doc-number>14643719</doc-number></document-id></child-doc></relation></continuation-in-part>
+<us-provisional-application><document-id><country>US</country><doc-number>61950001</doc-number><date>20140310</date></document-id></us-provisional-application>
+</us-related-documents>
+<us-field-of-classification-search>
+<classification-national><country>US</country><main-classification>425542</main-classification></classification-national>
+<classification-national><country>US</country><main-classification>None</main-classification><additional-info>unstructured</additional-info></classification-national>
+<classification-cpc-text>B29C 45/17</classification-cpc-text>
+</us-field-of-classification-search>
+<us-parties>
+<us-applicants>
+<us-applicant sequence="001" app-type="applicant" designation="us-only" applicant-authority-category="assignee">
+<addressbook><orgname>Acme Molding Co.</orgname><address><city>Osaka</city><country>JP</country></address></addressbook>
+<residence><country>JP</country></residence>
+</us-applicant>
+</us-applicants>
+<inventors>
+<inventor sequence="001" designation="us-only"><addressbook><last-name>Tanaka</last-name><first-name>Hiro</first-name><address><city>Osaka</city><country>JP</country></address></addressbook></inventor>
+<inventor sequence="002" designation="us-only"><addressbook><last-name>Smith</last-name><first-name>Ann</first-name><address><city>Austin</city><state>TX</state><country>US</country></address></addressbook></inventor>
+</inventors>
+<agents>
+<agent sequence="01" rep-type="attorney"><addressbook><orgname>Law Firm LLP</orgname><address><country>unknown</country></address></addressbook></agent>
+</agents>
+</us-parties>
+<assignees>
+<assignee><addressbook><orgname>Acme Molding Co.</orgname><role>03</role><address><city>Osaka</city><country>JP</country></address></addressbook></assignee>
+</assignees>
+<examiners>
+<primary-examiner><last-name>Heitbrink</last-name><first-name>Jill L</first-name><department>1743</department></primary-examiner>
+<assistant-examiner><last-name>Robitaille</last-name><first-name>John P</first-name></assistant-examiner>
+</examiners>
+<pct-or-regional-filing-data><document-id><country>WO</country><doc-number>PCT/JP2014/001234</doc-number><kind>00</kind><date>20140310</date></document-id><us-371c124-date><date>20150310</date></us-371c124-date></pct-or-regional-filing-data>
+<pct-or-regional-publishing-data><document-id><country>WO</country><doc-number>WO2014/141234</doc-number><kind>A1</kind><date>20140918</date></document-id></pct-or-regional-publishing-data>
+</us-bibliographic-data-grant>
+<abstract id="abstract"><p id="p-0001" num="0000">A machine.</p></abstract>
+<description id="description"><p id="p-0002" num="0001">Text.</p></description>
+<claims id="claims"><claim id="CLM-00001" num="00001"><claim-text>1. A machine.</claim-text></claim></claims>
+</us-patent-grant>`
+
+const sampleApplicationV41 = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE us-patent-application SYSTEM "us-patent-application-v41-2005-08-25.dtd" [ ]>
+<us-patent-application lang="EN" dtd-version="v4.1 2005-08-25" file="US20060000001A1-20060105.XML" status="PRODUCTION" id="us-patent-application" country="US" date-produced="20051221" date-publ="20060105">
+<us-bibliographic-data-application lang="EN" country="US">
+<publication-reference><document-id><country>US</country><doc-number>20060000001</doc-number><kind>A1</kind><date>20060105</date></document-id></publication-reference>
+<application-reference appl-type="utility"><document-id><country>US</country><doc-number>10873432</doc-number><date>20040621</date></document-id></application-reference>
+<invention-title id="d0e43">Textile treatment</invention-title>
+<us-related-documents>
+<division><relation><parent-doc><document-id><country>US</country><doc-number>10100001</doc-number><date>20020301</date></document-id><parent-status>ABANDONED</parent-status></parent-doc><child-doc><document-id><country>US</country><doc-number>10873432</doc-number></document-id></child-doc></relation></division>
+</us-related-documents>
+<parties>
+<applicants>
+<applicant sequence="00" app-type="applicant-inventor" designation="us-only">
+<addressbook><last-name>Doe</last-name><first-name>John</first-name><middle-name>Q.</middle-name><address><city>Greenville</city><state>SC</state><country>US</country></address></addressbook>
+<nationality><country>omitted</country></nationality>
+<residence><country>US</country></residence>
+</applicant>
+</applicants>
+</parties>
+</us-bibliographic-data-application>
+</us-patent-application>`
+
+func TestUnmarshalXmlPatentGrantParties(t *testing.T) {
+	patent, err := UnmarshalXmlPatent([]byte(sampleGrantV45), "grant", nil, &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalXmlPatent returned an error: %v", err)
+	}
+
+	biblio := patent.UsBibliographicData
+	if got := biblio.PublicationReference.DocumentID.DocNumber; got != "10000001" {
+		t.Errorf("DocNumber = %q, bibliographic data was not unmarshaled", got)
+	}
+	if biblio.InventionTitle.Text != "Injection molding machine" || biblio.NumberOfClaims != 2 {
+		t.Errorf("unexpected title or claim count: %q, %d", biblio.InventionTitle.Text, biblio.NumberOfClaims)
+	}
+
+	parties := biblio.Parties
+	if len(parties.Applicants) != 1 || parties.Applicants[0].Organization != "Acme Molding Co." || parties.Applicants[0].Residence != "JP" || parties.Applicants[0].Role != "applicant" {
+		t.Errorf("unexpected applicants: %+v", parties.Applicants)
+	}
+	if len(parties.Inventors) != 2 || parties.Inventors[1].LastName != "Smith" || parties.Inventors[1].Address.State != "TX" || parties.Inventors[1].Sequence != "002" {
+		t.Errorf("unexpected inventors: %+v", parties.Inventors)
+	}
+	if len(parties.Agents) != 1 || parties.Agents[0].Organization != "Law Firm LLP" || parties.Agents[0].Role != "attorney" {
+		t.Errorf("unexpected agents: %+v", parties.Agents)
+	}
+	if len(biblio.Assignees) != 1 || biblio.Assignees[0].Role != "03" || biblio.Assignees[0].Address.Country != "JP" {
+		t.Errorf("unexpected assignees: %+v", biblio.Assignees)
+	}
+}
+
+func TestUnmarshalXmlPatentApplicationInventorsFromApplicants(t *testing.T) {
+	patent, err := UnmarshalXmlPatent([]byte(sampleApplicationV41), "application", nil, &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalXmlPatent returned an error: %v", err)
+	}
+
+	biblio := patent.UsBibliographicData
+	if got := biblio.PublicationReference.DocumentID.DocNumber; got != "20060000001" {
+		t.Errorf("DocNumber = %q", got)
+	}
+
+	parties := biblio.Parties
+	if len(parties.Applicants) != 1 || parties.Applicants[0].Role != "applicant-inventor" {
+		t.Fatalf("unexpected applicants: %+v", parties.Applicants)
+	}
+	if len(parties.Inventors) != 1 {
+		t.Fatalf("expected the applicant-inventor to be listed as an inventor, got %+v", parties.Inventors)
+	}
+	if inv := parties.Inventors[0]; inv.LastName != "Doe" || inv.FirstName != "John Q." || inv.Residence != "US" {
+		t.Errorf("unexpected inventor: %+v", inv)
+	}
+}
+
+func TestUnmarshalXmlPatentGrantClassifications(t *testing.T) {
+	patent, err := UnmarshalXmlPatent([]byte(sampleGrantV45), "grant", nil, &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalXmlPatent returned an error: %v", err)
+	}
+
+	classifications := patent.UsBibliographicData.Classifications
+	if len(classifications.IPCR) != 1 {
+		t.Fatalf("expected 1 IPCR classification, got %+v", classifications.IPCR)
+	}
+	if ipc := classifications.IPCR[0]; ipc.Symbol != "B29C 45/17" || !ipc.Main || ipc.VersionDate != "20060101" || ipc.GeneratingOffice != "US" {
+		t.Errorf("unexpected IPCR classification: %+v", ipc)
+	}
+
+	if len(classifications.CPC) != 2 {
+		t.Fatalf("expected 2 CPC classifications, got %+v", classifications.CPC)
+	}
+	if cpc := classifications.CPC[0]; cpc.Symbol != "B29C 45/1775" || !cpc.Main || cpc.SchemeOriginationCode != "C" {
+		t.Errorf("unexpected main CPC classification: %+v", cpc)
+	}
+	if cpc := classifications.CPC[1]; cpc.Symbol != "B29C 2045/1784" || cpc.Main || cpc.Value != "A" {
+		t.Errorf("unexpected further CPC classification: %+v", cpc)
+	}
+
+	national := patent.UsBibliographicData.ClassificationNational
+	if len(national.FurtherClassifications) != 2 || national.FurtherClassification != "425588" {
+		t.Errorf("unexpected national classification: %+v", national)
+	}
+}
+
+func TestUnmarshalXmlPatentGrantCitations(t *testing.T) {
+	patent, err := UnmarshalXmlPatent([]byte(sampleGrantV45), "grant", nil, &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalXmlPatent returned an error: %v", err)
+	}
+
+	citations := patent.Citations
+	if len(citations.Patent) != 2 {
+		t.Fatalf("expected 2 patent citations, got %+v", citations.Patent)
+	}
+	if c := citations.Patent[0]; c.DocNumber != "4828475" || c.Name != "Ueno et al." || c.Category != types.CitedByExaminer || c.Sequence != "00001" {
+		t.Errorf("unexpected patent citation: %+v", c)
+	}
+	if c := citations.Patent[1]; c.Country != "JP" || c.Category != types.CitedByApplicant {
+		t.Errorf("unexpected patent citation: %+v", c)
+	}
+
+	if len(citations.NonPatent) != 1 {
+		t.Fatalf("expected 1 non-patent citation, got %+v", citations.NonPatent)
+	}
+	if c := citations.NonPatent[0]; c.Text != "Smith, \u201cMolding in situ,\u201d J. Polym. Sci., 2010." || c.Category != types.CitedByApplicant {
+		t.Errorf("unexpected non-patent citation: %+v", c)
+	}
+}
+
+func TestUnmarshalXmlPatentRelatedDocuments(t *testing.T) {
+	grant, err := UnmarshalXmlPatent([]byte(sampleGrantV45), "grant", nil, &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalXmlPatent returned an error: %v", err)
+	}
+
+	related := grant.UsBibliographicData.RelatedDocuments
+	if len(related) != 2 {
+		t.Fatalf("expected 2 related documents, got %+v", related)
+	}
+	cip := related[0]
+	if cip.Type != types.RelationContinuationInPart || cip.Parent.DocNumber != "13000001" || cip.Parent.Date != "20110105" || cip.ParentStatus != "GRANTED" {
+		t.Errorf("unexpected continuation-in-part: %+v", cip)
+	}
+	if cip.ParentGrant == nil || cip.ParentGrant.DocNumber != "8900000" || cip.Child == nil || cip.Child.DocNumber != "14643719" {
+		t.Errorf("unexpected continuation-in-part parent grant or child: %+v", cip)
+	}
+	if provisional := related[1]; provisional.Type != types.RelationProvisionalApplication || provisional.Parent.DocNumber != "61950001" || provisional.Child != nil {
+		t.Errorf("unexpected provisional: %+v", provisional)
+	}
+
+	priority := grant.UsBibliographicData.PriorityClaims
+	if len(priority) != 1 || priority[0].Country != "JP" || priority[0].Kind != "national" || priority[0].Date != "20140314" {
+		t.Errorf("unexpected priority claims: %+v", priority)
+	}
+
+	application, err := UnmarshalXmlPatent([]byte(sampleApplicationV41), "application", nil, &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalXmlPatent returned an error: %v", err)
+	}
+	related = application.UsBibliographicData.RelatedDocuments
+	if len(related) != 1 || related[0].Type != types.RelationDivision || related[0].ParentStatus != "ABANDONED" {
+		t.Errorf("unexpected application related documents: %+v", related)
+	}
+}
+
+func TestUnmarshalXmlPatentGrantProsecution(t *testing.T) {
+	patent, err := UnmarshalXmlPatent([]byte(sampleGrantV45), "grant", nil, &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalXmlPatent returned an error: %v", err)
+	}
+	biblio := patent.UsBibliographicData
+
+	if term := biblio.TermOfGrant; term == nil || term.ExtensionDays != 312 || term.Disclaimer != "This patent is subject to a terminal disclaimer." {
+		t.Errorf("unexpected term of grant: %+v", term)
+	}
+
+	examiners := biblio.Examiners
+	if examiners == nil || examiners.Primary == nil || examiners.Primary.LastName != "Heitbrink" || examiners.Primary.Department != "1743" {
+		t.Fatalf("unexpected primary examiner: %+v", examiners)
+	}
+	if examiners.Assistant == nil || examiners.Assistant.FirstName != "John P" {
+		t.Errorf("unexpected assistant examiner: %+v", examiners.Assistant)
+	}
+
+	if pct := biblio.PCTFiling; pct == nil || pct.DocumentID.DocNumber != "PCT/JP2014/001234" || pct.Date371 != "20150310" {
+		t.Errorf("unexpected PCT filing: %+v", pct)
+	}
+	if pub := biblio.PCTPublication; pub == nil || pub.DocNumber != "WO2014/141234" || pub.Kind != "A1" {
+		t.Errorf("unexpected PCT publication: %+v", pub)
+	}
+
+	search := biblio.FieldOfSearch
+	if search == nil || len(search.National) != 2 || search.National[1].AdditionalInfo != "unstructured" || len(search.CPC) != 1 || search.CPC[0] != "B29C 45/17" {
+		t.Errorf("unexpected field of search: %+v", search)
+	}
+
+	application, err := UnmarshalXmlPatent([]byte(sampleApplicationV41), "application", nil, &mockLogger{})
+	if err != nil {
+		t.Fatalf("UnmarshalXmlPatent returned an error: %v", err)
+	}
+	if application.UsBibliographicData.TermOfGrant != nil || application.UsBibliographicData.Examiners != nil {
+		t.Errorf("expected no term of grant or examiners on an application")
+	}
+}
@@ -0,0 +1,92 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/diverged/uspt-go/internal/parsers/apsparser"
+	"github.com/diverged/uspt-go/internal/stats"
+	"github.com/diverged/uspt-go/internal/transformtext"
+	"github.com/diverged/uspt-go/internal/utils"
+	"github.com/diverged/uspt-go/types"
+)
+
+// APSPipeline is the processing logic flow for the fixed-field APS text files of 1976-2001 grants (pftaps*.zip).
+func APSPipeline(ctx context.Context, source *utils.BulkSource, zipProfile *types.USPTGoMetadata, cfg *types.USPTGoConfig, zipStats *stats.Zip, docChan chan<- *types.USPTGoDoc, errChan chan<- error) {
+
+	bulkZip := zipProfile.OriginZip
+	log := cfg.Logger
+	tuning := cfg.Tuning
+	log.Info("Starting APSPipeline", "Bulk Zip File", bulkZip.ZipName, "Parser Workers", tuning.ParserWorkers, "Translator Workers", tuning.TranslatorWorkers)
+
+	splitAPSDocChan := make(chan *types.USPTGoDoc, bufferSize(tuning)) // BulkAPSSplitter() => splitAPSDocChan => ParseAPSPatent()
+
+	// The stages take what they need of zipProfile before the splitter starts updating it with each document
+	var senders sync.WaitGroup // Every goroutine which sends to errChan, which is closed once they have all returned
+	senders.Add(1)
+	apsStages(ctx, zipProfile, cfg, zipStats, splitAPSDocChan, docChan, errChan, &senders)
+
+	// Start BulkAPSSplitter() in goroutine
+	go func() {
+		log.Info("Initializing APS Bulk Splitter", "Splitting", bulkZip.ZipName)
+		defer senders.Done()
+		defer close(splitAPSDocChan)
+		utils.BulkAPSSplitter(ctx, source, zipProfile, splitAPSDocChan, errChan, log)
+	}()
+}
+
+// apsStages runs the split APS records received on splitAPSDocChan through the stages following the splitter
+func apsStages(ctx context.Context, zipProfile *types.USPTGoMetadata, cfg *types.USPTGoConfig, zipStats *stats.Zip, splitAPSDocChan <-chan *types.USPTGoDoc, docChan chan<- *types.USPTGoDoc, errChan chan<- error, senders *sync.WaitGroup) {
+
+	bulkZip := zipProfile.OriginZip
+	log := cfg.Logger
+	tuning := cfg.Tuning
+
+	// Create blocking channels
+	parsedAPSDocChan := make(chan *types.USPTGoDoc, bufferSize(tuning)) // ParseAPSPatent() => parsedAPSDocChan
+	transDocChan := make(chan *types.USPTGoDoc, bufferSize(tuning))     // TranslatePatentXmlToHtml() => transDocChan
+
+	// Count the split records, then drop those rejected by Filter.Raw and Filter.Header ahead of parsing
+	splitDocChan := meter(ctx, tuning, splitAPSDocChan, zipStats, &zipStats.Split, stats.StageSplit)
+	prefilteredDocChan := prefilter(ctx, cfg, splitDocChan, func(doc *types.USPTGoDoc) (types.DocHeader, error) {
+		return apsparser.ReadHeader(doc.RawSplitDoc)
+	}, zipStats)
+
+	// Start ParseAPSPatent() in goroutine
+	senders.Add(1)
+	go func() {
+		defer senders.Done()
+		log.Info("Initializing parsing of split APS patent records")
+		defer close(parsedAPSDocChan)
+		runStage(ctx, tuning.ParserWorkers, tuning.PreserveOrder, prefilteredDocChan, parsedAPSDocChan, func(in <-chan *types.USPTGoDoc, out chan<- *types.USPTGoDoc) {
+			apsparser.ParseAPSPatent(ctx, cfg, in, out, errChan, log)
+		})
+	}()
+
+	// Count the parsed records, then drop those rejected by Filter.Patent ahead of translation
+	parsedDocChan := meter(ctx, tuning, parsedAPSDocChan, zipStats, &zipStats.Parsed, stats.StageParse)
+	filteredDocChan := postfilter(ctx, cfg, parsedDocChan, zipStats)
+
+	// APS text sections are rendered as XML-style paragraphs, so they share the XML to HTML translation
+	senders.Add(1)
+	go func() {
+		defer senders.Done()
+		defer close(transDocChan)
+		if !cfg.Projection.IncludesDescription() {
+			passThrough(ctx, filteredDocChan, transDocChan)
+			return
+		}
+		log.Info("Initializing XML to HTML translation")
+		runStage(ctx, tuning.TranslatorWorkers, tuning.PreserveOrder, filteredDocChan, transDocChan, func(in <-chan *types.USPTGoDoc, out chan<- *types.USPTGoDoc) {
+			transformtext.TranslatePatentXmlToHtml(ctx, cfg, in, out, errChan, log)
+		})
+	}()
+
+	// Forward on the channel contents, counting the translated documents when translation ran
+	var finishedDocChan <-chan *types.USPTGoDoc = transDocChan
+	if cfg.Projection.IncludesDescription() {
+		finishedDocChan = meter(ctx, tuning, transDocChan, zipStats, &zipStats.Translated, stats.StageTranslate)
+	}
+	go forward(ctx, bulkZip, cfg.ReturnRawSplitDoc, finishedDocChan, docChan, errChan, senders, log)
+
+}
@@ -0,0 +1,73 @@
+package pipeline
+
+import (
+	"context"
+
+	"github.com/diverged/uspt-go/internal/stats"
+	"github.com/diverged/uspt-go/internal/utils"
+	"github.com/diverged/uspt-go/types"
+)
+
+// headerReader reads the header fields of a split document for Filter.Header
+type headerReader func(doc *types.USPTGoDoc) (types.DocHeader, error)
+
+// prefilter applies Filter.Raw and Filter.Header to the split documents on in, on the parser's worker pool, returning the channel of accepted documents.
+// When neither phase is set, in is returned as is.  readHeader is nil for documents without a patent header, which skip Filter.Header.
+func prefilter(ctx context.Context, cfg *types.USPTGoConfig, in <-chan *types.USPTGoDoc, readHeader headerReader, zipStats *stats.Zip) <-chan *types.USPTGoDoc {
+	filter := cfg.Filter
+	if filter.Raw == nil && (filter.Header == nil || readHeader == nil) {
+		return in
+	}
+
+	out := make(chan *types.USPTGoDoc, bufferSize(cfg.Tuning))
+	go func() {
+		defer close(out)
+		runStage(ctx, cfg.Tuning.ParserWorkers, cfg.Tuning.PreserveOrder, in, out, func(in <-chan *types.USPTGoDoc, out chan<- *types.USPTGoDoc) {
+			for doc := range in {
+				if filter.Raw != nil && !filter.Raw(doc.RawSplitDoc, doc.USPTGoMetadata) {
+					zipStats.Prefiltered.Add(1)
+					continue
+				}
+				if filter.Header != nil && readHeader != nil {
+					header, err := readHeader(doc)
+					// A document whose header can't be read is left for the parser to report
+					if err == nil && !filter.Header(header) {
+						zipStats.Prefiltered.Add(1)
+						continue
+					}
+					if err != nil {
+						cfg.Logger.Debug("Unable to read document header for Filter.Header", "DocName", doc.USPTGoMetadata.OriginZip.IndexName, "error", err)
+					}
+				}
+				if !utils.SendDoc(ctx, out, doc) {
+					utils.Drain(in)
+					return
+				}
+			}
+		})
+	}()
+	return out
+}
+
+// postfilter applies Filter.Patent to the parsed documents on in, ahead of translation, returning the channel of accepted documents.  When Filter.Patent is not set, in is returned as is.
+func postfilter(ctx context.Context, cfg *types.USPTGoConfig, in <-chan *types.USPTGoDoc, zipStats *stats.Zip) <-chan *types.USPTGoDoc {
+	if cfg.Filter.Patent == nil {
+		return in
+	}
+
+	out := make(chan *types.USPTGoDoc, bufferSize(cfg.Tuning))
+	go func() {
+		defer close(out)
+		for doc := range in {
+			if !cfg.Filter.Patent(&doc.Patent) {
+				zipStats.Postfiltered.Add(1)
+				continue
+			}
+			if !utils.SendDoc(ctx, out, doc) {
+				utils.Drain(in)
+				return
+			}
+		}
+	}()
+	return out
+}
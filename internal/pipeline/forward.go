@@ -0,0 +1,43 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/diverged/uspt-go/internal/utils"
+	"github.com/diverged/uspt-go/types"
+)
+
+// forward relays the finished documents to docChan, closing docChan once transDocChan is closed and errChan once every stage holding it, tracked by senders, has returned.
+// Unless returnRaw is set, the raw split document is dropped from each document so that its memory can be reclaimed.
+// When ctx is cancelled the remaining documents are drained and dropped, and ctx.Err() is reported on errChan before it closes.
+func forward(ctx context.Context, bulkZip types.OriginZip, returnRaw bool, transDocChan <-chan *types.USPTGoDoc, docChan chan<- *types.USPTGoDoc, errChan chan<- error, senders *sync.WaitGroup, log types.Logger) {
+
+	defer close(docChan)
+	defer close(errChan)
+	defer senders.Wait() // Runs before errChan is closed
+	for doc := range transDocChan {
+		if !returnRaw {
+			doc.RawSplitDoc = nil
+			doc.Trademark.RawSplitDoc = nil
+		}
+		utils.SendDoc(ctx, docChan, doc)
+	}
+
+	if err := ctx.Err(); err != nil {
+		log.Info("Pipeline cancelled", "Bulk Zip File", bulkZip.ZipName, "error", err)
+		select {
+		case errChan <- &types.USPTGoError{
+			Err:     err,
+			Kind:    types.ErrCancelled,
+			Skipped: true,
+			Name:    bulkZip.ZipName,
+			Type:    "zip",
+			Whence:  "processing the zip file",
+			ZipInfo: bulkZip,
+		}:
+		default:
+			// errChan is full and no longer being read, so there is no one left to tell
+		}
+	}
+}
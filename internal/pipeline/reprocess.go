@@ -0,0 +1,54 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+
+	"github.com/diverged/uspt-go/internal/stats"
+	"github.com/diverged/uspt-go/internal/utils"
+	"github.com/diverged/uspt-go/types"
+)
+
+// ReprocessPipeline runs dead letters of a single origin, as described by zipProfile, through the stages of the pipeline which skipped them.
+// The letters stand in for the splitter, keeping their original OriginZip.  Patent documents are matched to their schema afresh, so a schema registered since is applied.
+func ReprocessPipeline(ctx context.Context, letters []types.DeadLetterRecord, zipProfile *types.USPTGoMetadata, cfg *types.USPTGoConfig, zipStats *stats.Zip, docChan chan<- *types.USPTGoDoc, errChan chan<- error) {
+
+	log := cfg.Logger
+	log.Info("Starting ReprocessPipeline", "Bulk Zip File", zipProfile.OriginZip.ZipName, "Dead Letters", len(letters))
+
+	splitDocChan := make(chan *types.USPTGoDoc, bufferSize(cfg.Tuning)) // letters => splitDocChan
+
+	var senders sync.WaitGroup // Every goroutine which sends to errChan, which is closed once they have all returned
+	senders.Add(1)
+	go func() {
+		defer senders.Done()
+		defer close(splitDocChan)
+		for _, letter := range letters {
+			doc := &types.USPTGoDoc{
+				USPTGoMetadata: letter.Metadata,
+				RawSplitDoc:    letter.RawSplitDoc,
+			}
+			switch {
+			case zipProfile.DocumentType == "trademark":
+				doc.Trademark.RawSplitDoc = letter.RawSplitDoc
+			case zipProfile.OriginZip.ZipEntryExt != ".txt":
+				if schemaErr := utils.ApplySchema(doc); schemaErr != nil {
+					if !utils.SendErr(ctx, errChan, schemaErr) {
+						return
+					}
+					continue
+				}
+			}
+			if !utils.SendDoc(ctx, splitDocChan, doc) {
+				return
+			}
+		}
+	}()
+
+	switch zipProfile.OriginZip.ZipEntryExt {
+	case ".txt":
+		apsStages(ctx, zipProfile, cfg, zipStats, splitDocChan, docChan, errChan, &senders)
+	default:
+		xmlStages(ctx, zipProfile, cfg, zipStats, splitDocChan, docChan, errChan, &senders)
+	}
+}
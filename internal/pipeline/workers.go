@@ -0,0 +1,124 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/diverged/uspt-go/internal/stats"
+	"github.com/diverged/uspt-go/internal/utils"
+	"github.com/diverged/uspt-go/types"
+)
+
+const defaultBufferSize = 100
+
+// stageFunc is a pipeline stage which ranges over in until it is closed, sending at most one document to out for each one received
+type stageFunc func(in <-chan *types.USPTGoDoc, out chan<- *types.USPTGoDoc)
+
+// bufferSize returns the capacity of the channels between stages
+func bufferSize(tuning types.Tuning) int {
+	if tuning.BufferSize > 0 {
+		return tuning.BufferSize
+	}
+	return defaultBufferSize
+}
+
+// runStage runs stage on the given number of workers and returns once every worker has returned.  The caller closes out.
+// Parallel workers finish documents out of order, so with preserveOrder each document is handed to a worker on its own and the results are collected in arrival order.
+func runStage(ctx context.Context, workers int, preserveOrder bool, in <-chan *types.USPTGoDoc, out chan<- *types.USPTGoDoc, stage stageFunc) {
+	if workers < 1 {
+		workers = 1
+	}
+
+	if workers == 1 || !preserveOrder {
+		var wg sync.WaitGroup
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				stage(in, out)
+			}()
+		}
+		wg.Wait()
+		return
+	}
+
+	type job struct {
+		in  chan *types.USPTGoDoc
+		out chan *types.USPTGoDoc
+	}
+	jobs := make(chan job)
+	pending := make(chan chan *types.USPTGoDoc, workers*2) // Each job's result channel, in arrival order
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				stage(j.in, j.out)
+				close(j.out)
+			}
+		}()
+	}
+
+	// Feed the workers, queueing each result channel before its job so the collector waits on them in order.  Once cancelled, the rest of in is drained so that upstream stages finish before out is closed.
+	go func() {
+		defer close(jobs)
+		defer close(pending)
+		for doc := range in {
+			j := job{in: make(chan *types.USPTGoDoc, 1), out: make(chan *types.USPTGoDoc, 1)}
+			j.in <- doc
+			close(j.in)
+
+			select {
+			case pending <- j.out:
+			case <-ctx.Done():
+				utils.Drain(in)
+				return
+			}
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				close(j.out) // Queued but never run
+				utils.Drain(in)
+				return
+			}
+		}
+	}()
+
+	for results := range pending {
+		for doc := range results {
+			utils.SendDoc(ctx, out, doc)
+		}
+	}
+	wg.Wait()
+}
+
+// passThrough relays every document from in to out unchanged, standing in for a stage which has nothing to do
+func passThrough(ctx context.Context, in <-chan *types.USPTGoDoc, out chan<- *types.USPTGoDoc) {
+	for doc := range in {
+		if !utils.SendDoc(ctx, out, doc) {
+			utils.Drain(in)
+			return
+		}
+	}
+}
+
+// meter relays every document from in to the returned channel, counting each into counter, and records the completion of stage once in is closed.
+// Once cancelled, the rest of in is drained uncounted, so that the stage is only recorded as done, and out closed, when the stage upstream has finished.
+func meter(ctx context.Context, tuning types.Tuning, in <-chan *types.USPTGoDoc, zipStats *stats.Zip, counter *atomic.Int64, stage stats.Stage) <-chan *types.USPTGoDoc {
+	out := make(chan *types.USPTGoDoc, bufferSize(tuning))
+	go func() {
+		defer close(out)
+		defer zipStats.StageDone(stage)
+		for doc := range in {
+			counter.Add(1)
+			if !utils.SendDoc(ctx, out, doc) {
+				utils.Drain(in)
+				return
+			}
+		}
+	}()
+	return out
+}
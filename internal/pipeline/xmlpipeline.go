@@ -1,61 +1,122 @@
 package pipeline
 
 import (
+	"context"
+	"sync"
+
 	"github.com/diverged/uspt-go/internal/parsers/xmlparser"
+	"github.com/diverged/uspt-go/internal/stats"
 	"github.com/diverged/uspt-go/internal/transformtext"
 	"github.com/diverged/uspt-go/internal/utils"
 	"github.com/diverged/uspt-go/types"
 )
 
-// XMLPipeline is the processing logic flow for bulk XML patent files of both Grant and Application types.
-func XMLPipeline(zipProfile *types.USPTGoMetadata, cfg *types.USPTGoConfig, docChan chan<- *types.USPTGoDoc, errChan chan<- error) {
+// XMLPipeline is the processing logic flow for bulk XML patent files of both Grant and Application types, and for trademark daily XML files.
+func XMLPipeline(ctx context.Context, source *utils.BulkSource, zipProfile *types.USPTGoMetadata, cfg *types.USPTGoConfig, zipStats *stats.Zip, docChan chan<- *types.USPTGoDoc, errChan chan<- error) {
 
 	bulkZip := zipProfile.OriginZip
 	log := cfg.Logger
-	log.Info("Starting XMLPipeline", "Bulk Zip File", bulkZip.ZipName)
+	tuning := cfg.Tuning
+	log.Info("Starting XMLPipeline", "Bulk Zip File", bulkZip.ZipName, "Parser Workers", tuning.ParserWorkers, "Translator Workers", tuning.TranslatorWorkers)
 
-	// Create blocking channels
-	splitXMLDocChan := make(chan *types.USPTGoDoc, 100)  // BulkXMLSplitter() => splitXMLDocChan => XMLParser()
-	parsedXMLDocChan := make(chan *types.USPTGoDoc, 100) // XMLParser() => parsedXMLDocChan
-	transDocChan := make(chan *types.USPTGoDoc, 100)     // XMLParser() => transDocChan
+	splitXMLDocChan := make(chan *types.USPTGoDoc, bufferSize(tuning)) // BulkXMLSplitter() => splitXMLDocChan => XMLParser()
+
+	// The stages take what they need of zipProfile before the splitter starts updating it with each document
+	var senders sync.WaitGroup // Every goroutine which sends to errChan, which is closed once they have all returned
+	senders.Add(1)
+	xmlStages(ctx, zipProfile, cfg, zipStats, splitXMLDocChan, docChan, errChan, &senders)
 
 	// Start BulkXMLSplitter() in goroutine
 	go func() {
 		log.Info("Initializing XML Bulk Splitter", "Splitting", bulkZip.ZipName)
+		defer senders.Done()
 		defer close(splitXMLDocChan)
-		utils.BulkXMLSplitter(zipProfile, splitXMLDocChan, errChan, log)
+		switch zipProfile.DocumentType {
+		case "trademark":
+			utils.BulkTrademarkSplitter(ctx, source, zipProfile, splitXMLDocChan, errChan, log)
+		default:
+			utils.BulkXMLSplitter(ctx, source, zipProfile, splitXMLDocChan, errChan, log)
+		}
 	}()
+}
+
+// xmlStages runs the split XML documents received on splitXMLDocChan through the stages following the splitter
+func xmlStages(ctx context.Context, zipProfile *types.USPTGoMetadata, cfg *types.USPTGoConfig, zipStats *stats.Zip, splitXMLDocChan <-chan *types.USPTGoDoc, docChan chan<- *types.USPTGoDoc, errChan chan<- error, senders *sync.WaitGroup) {
+
+	bulkZip := zipProfile.OriginZip
+	log := cfg.Logger
+	tuning := cfg.Tuning
 
-	// Start ParseXMLPatent() in go routine
+	// Create blocking channels
+	parsedXMLDocChan := make(chan *types.USPTGoDoc, bufferSize(tuning)) // XMLParser() => parsedXMLDocChan
+	transDocChan := make(chan *types.USPTGoDoc, bufferSize(tuning))     // XMLParser() => transDocChan
+
+	// Count the split documents, then drop those rejected by Filter.Raw and Filter.Header ahead of parsing.  Trademarks have no patent header.
+	var readHeader headerReader
+	if zipProfile.DocumentType != "trademark" {
+		readHeader = func(doc *types.USPTGoDoc) (types.DocHeader, error) {
+			definition, _ := types.LookupSchema(doc.USPTGoMetadata.OriginZip.Schema)
+			return xmlparser.ReadHeader(doc.RawSplitDoc, doc.USPTGoMetadata.DocumentType, definition.Format)
+		}
+	}
+	splitDocChan := meter(ctx, tuning, splitXMLDocChan, zipStats, &zipStats.Split, stats.StageSplit)
+	prefilteredDocChan := prefilter(ctx, cfg, splitDocChan, readHeader, zipStats)
+
+	// Start the ParseXMLPatent() workers in go routine
+	senders.Add(1)
 	go func() {
+		defer senders.Done()
 		defer close(parsedXMLDocChan)
 
+		var parser stageFunc
 		switch zipProfile.DocumentType {
 		case "application", "grant":
 			log.Info("Initializing parsing of split XML Patent docs")
-			xmlparser.ParseXMLPatent(cfg, splitXMLDocChan, parsedXMLDocChan, errChan, log)
-		// case "trademark":
-		// log.Info("Initializing parsing of split XML trademark docs")
+			parser = func(in <-chan *types.USPTGoDoc, out chan<- *types.USPTGoDoc) {
+				xmlparser.ParseXMLPatent(ctx, cfg, in, out, errChan, log)
+			}
+		case "trademark":
+			log.Info("Initializing parsing of split XML trademark docs")
+			parser = func(in <-chan *types.USPTGoDoc, out chan<- *types.USPTGoDoc) {
+				xmlparser.ParseXMLTrademark(ctx, cfg, in, out, errChan, log)
+			}
 		default:
 			log.Error("XMLPipeline() couldn't match DocumentType when assigning a parser")
+			utils.Drain(prefilteredDocChan)
+			return
 		}
+		runStage(ctx, tuning.ParserWorkers, tuning.PreserveOrder, prefilteredDocChan, parsedXMLDocChan, parser)
 	}()
 
+	// Count the parsed documents, then drop those rejected by Filter.Patent ahead of translation
+	filteredDocChan := meter(ctx, tuning, parsedXMLDocChan, zipStats, &zipStats.Parsed, stats.StageParse)
+	if zipProfile.DocumentType != "trademark" {
+		filteredDocChan = postfilter(ctx, cfg, filteredDocChan, zipStats)
+	}
+
 	// Start TranslatePatentXmlToHtml() in go routine to translate XML to HTML
+	senders.Add(1)
 	go func() {
-		log.Info("Initializing XML to HTML translation")
+		defer senders.Done()
 		defer close(transDocChan)
-		transformtext.TranslatePatentXmlToHtml(parsedXMLDocChan, transDocChan, errChan, log)
-	}()
 
-	// Forward on the channel contents
-	go func() {
-
-		defer close(docChan)
-		defer close(errChan)
-		for doc := range transDocChan {
-			docChan <- doc
+		// Trademarks have no description to translate, nor does a projection without the description
+		if zipProfile.DocumentType == "trademark" || !cfg.Projection.IncludesDescription() {
+			passThrough(ctx, filteredDocChan, transDocChan)
+			return
 		}
+
+		log.Info("Initializing XML to HTML translation")
+		runStage(ctx, tuning.TranslatorWorkers, tuning.PreserveOrder, filteredDocChan, transDocChan, func(in <-chan *types.USPTGoDoc, out chan<- *types.USPTGoDoc) {
+			transformtext.TranslatePatentXmlToHtml(ctx, cfg, in, out, errChan, log)
+		})
 	}()
 
+	// Forward on the channel contents, counting the translated documents when translation ran
+	var finishedDocChan <-chan *types.USPTGoDoc = transDocChan
+	if zipProfile.DocumentType != "trademark" && cfg.Projection.IncludesDescription() {
+		finishedDocChan = meter(ctx, tuning, transDocChan, zipStats, &zipStats.Translated, stats.StageTranslate)
+	}
+	go forward(ctx, bulkZip, cfg.ReturnRawSplitDoc, finishedDocChan, docChan, errChan, senders, log)
+
 }
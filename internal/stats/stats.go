@@ -0,0 +1,227 @@
+package stats
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// Run tracks the progress of every input of a run.  Its counters are updated concurrently by the pipelines and read by Snapshot at any time.
+type Run struct {
+	start time.Time
+	zips  []*Zip
+
+	mu       sync.Mutex
+	skipped  map[string]int // Errors reported by the Dispatcher itself rather than by a zip
+	errors   int
+	finished time.Time
+}
+
+// NewRun returns a Run tracking the inputs of the given paths
+func NewRun(paths []string) *Run {
+	r := &Run{start: time.Now(), skipped: make(map[string]int)}
+	for i, path := range paths {
+		r.zips = append(r.zips, &Zip{Path: path, Index: i, skipped: make(map[string]int)})
+	}
+	return r
+}
+
+// Zip returns the tracker of the input at index
+func (r *Run) Zip(index int) *Zip {
+	return r.zips[index]
+}
+
+// Error counts an error reported by the Dispatcher outside of any zip
+func (r *Run) Error(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors++
+	countSkipped(r.skipped, err)
+}
+
+// Finish marks the run as done, freezing its Elapsed time
+func (r *Run) Finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.finished = time.Now()
+}
+
+// Snapshot returns the current Stats of the run
+func (r *Run) Snapshot() types.Stats {
+	r.mu.Lock()
+	stats := types.Stats{Skipped: make(map[string]int), Done: !r.finished.IsZero()}
+	stats.Errors = r.errors
+	for whence, n := range r.skipped {
+		stats.Skipped[whence] += n
+	}
+	stats.Elapsed = since(r.start, r.finished)
+	r.mu.Unlock()
+
+	for _, zip := range r.zips {
+		zipStats := zip.Snapshot()
+		stats.Zips = append(stats.Zips, zipStats)
+
+		stats.Split += zipStats.Split
+		stats.Prefiltered += zipStats.Prefiltered
+		stats.Parsed += zipStats.Parsed
+		stats.Postfiltered += zipStats.Postfiltered
+		stats.Translated += zipStats.Translated
+		stats.Documents += zipStats.Documents
+		stats.Partial += zipStats.Partial
+		stats.Errors += zipStats.Errors
+		for whence, n := range zipStats.Skipped {
+			stats.Skipped[whence] += n
+		}
+		stats.BytesRead += zipStats.BytesRead
+		stats.BytesTotal += zipStats.BytesTotal
+	}
+
+	if seconds := stats.Elapsed.Seconds(); seconds > 0 {
+		stats.DocumentsPerSecond = float64(stats.Documents) / seconds
+		stats.BytesPerSecond = float64(stats.BytesRead) / seconds
+	}
+	return stats
+}
+
+// Stage names a pipeline stage whose completion is timed
+type Stage int
+
+const (
+	StageSplit Stage = iota
+	StageParse
+	StageTranslate
+	numStages
+)
+
+// Zip tracks the progress of a single input, updated concurrently by its pipeline stages
+type Zip struct {
+	Path  string
+	Index int
+
+	Split        atomic.Int64 // Documents split from the bulk file
+	Prefiltered  atomic.Int64 // Documents rejected by Filter.Raw or Filter.Header
+	Parsed       atomic.Int64
+	Postfiltered atomic.Int64 // Documents rejected by Filter.Patent
+	Translated   atomic.Int64
+	Documents    atomic.Int64 // Sent to the run's docChan
+	Partial      atomic.Int64 // Sent with Diagnostics
+	Errors       atomic.Int64 // Sent to the run's errChan
+
+	start     atomic.Int64 // Unix nanoseconds, zero until started
+	finished  atomic.Int64
+	stageDone [numStages]atomic.Int64
+
+	mu         sync.Mutex
+	skipped    map[string]int
+	bytesRead  func() int64
+	bytesTotal int64
+}
+
+// Start marks the beginning of the zip's pipeline
+func (z *Zip) Start() {
+	z.start.Store(time.Now().UnixNano())
+}
+
+// Finish marks the zip as done, once its last document and error have been sent
+func (z *Zip) Finish() {
+	z.finished.Store(time.Now().UnixNano())
+}
+
+// StageDone records the completion of a stage of the zip's pipeline
+func (z *Zip) StageDone(stage Stage) {
+	z.stageDone[stage].Store(time.Now().UnixNano())
+}
+
+// SetSource reports the size of the opened input, and the function reading how much of it has been consumed
+func (z *Zip) SetSource(bytesTotal int64, bytesRead func() int64) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	z.bytesTotal, z.bytesRead = bytesTotal, bytesRead
+}
+
+// Error counts an error sent to the run's errChan
+func (z *Zip) Error(err error) {
+	z.Errors.Add(1)
+	z.mu.Lock()
+	defer z.mu.Unlock()
+	countSkipped(z.skipped, err)
+}
+
+// Elapsed is the time spent on the zip so far, or in total once finished
+func (z *Zip) Elapsed() time.Duration {
+	start := z.start.Load()
+	if start == 0 {
+		return 0
+	}
+	var finished time.Time
+	if end := z.finished.Load(); end != 0 {
+		finished = time.Unix(0, end)
+	}
+	return since(time.Unix(0, start), finished)
+}
+
+// Snapshot returns the current ZipStats of the zip
+func (z *Zip) Snapshot() types.ZipStats {
+	zipStats := types.ZipStats{
+		StageCounts: types.StageCounts{
+			Split:        int(z.Split.Load()),
+			Prefiltered:  int(z.Prefiltered.Load()),
+			Parsed:       int(z.Parsed.Load()),
+			Postfiltered: int(z.Postfiltered.Load()),
+			Translated:   int(z.Translated.Load()),
+			Documents:    int(z.Documents.Load()),
+			Partial:      int(z.Partial.Load()),
+			Errors:       int(z.Errors.Load()),
+		},
+		ZipPath:  z.Path,
+		Index:    z.Index,
+		Started:  z.start.Load() != 0,
+		Finished: z.finished.Load() != 0,
+		Skipped:  make(map[string]int),
+		Elapsed:  z.Elapsed(),
+	}
+
+	if start := z.start.Load(); start != 0 {
+		stageTimes := [numStages]*time.Duration{&zipStats.SplitTime, &zipStats.ParseTime, &zipStats.TranslateTime}
+		for stage, stageTime := range stageTimes {
+			if done := z.stageDone[stage].Load(); done != 0 {
+				*stageTime = time.Duration(done - start)
+			}
+		}
+	}
+
+	z.mu.Lock()
+	for whence, n := range z.skipped {
+		zipStats.Skipped[whence] = n
+	}
+	zipStats.BytesTotal = z.bytesTotal
+	bytesRead := z.bytesRead
+	z.mu.Unlock()
+	if bytesRead != nil {
+		zipStats.BytesRead = bytesRead()
+	}
+
+	if seconds := zipStats.Elapsed.Seconds(); seconds > 0 {
+		zipStats.DocumentsPerSecond = float64(zipStats.Documents) / seconds
+	}
+	return zipStats
+}
+
+// countSkipped counts err by its Whence when it reports a skipped document or file
+func countSkipped(skipped map[string]int, err error) {
+	var usptgoErr *types.USPTGoError
+	if errors.As(err, &usptgoErr) && usptgoErr.Skipped {
+		skipped[usptgoErr.Whence]++
+	}
+}
+
+// since is the time from start until finished, or until now when finished is zero
+func since(start, finished time.Time) time.Duration {
+	if finished.IsZero() {
+		return time.Since(start)
+	}
+	return finished.Sub(start)
+}
@@ -1,22 +1,39 @@
 package transformtext
 
 import (
+	"context"
+
+	"github.com/diverged/uspt-go/internal/utils"
 	"github.com/diverged/uspt-go/types"
 )
 
-func TranslatePatentXmlToHtml(parsedXmlDocChan <-chan *types.USPTGoDoc, transDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
+// TranslatePatentXmlToHtml translates the description of each document to HTML.  A document whose description can't be translated is sent on with the description left as XML and a Diagnostic, and is also reported on errChan unless cfg.Lenient is set.
+func TranslatePatentXmlToHtml(ctx context.Context, cfg *types.USPTGoConfig, parsedXmlDocChan <-chan *types.USPTGoDoc, transDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
 	for doc := range parsedXmlDocChan {
 		// Translate the inner XML content to HTML
 		htmlDescription, err := InnerXmlToHtml([]byte(doc.Patent.Description.Content))
 		if err != nil {
-			errChan <- &types.USPTGoError{
-				Skipped: true,
-				Name:    doc.USPTGoMetadata.OriginZip.IndexName,
-				Type:    "xml translation",
+			log.Warn("Unable to translate the description to HTML", "DocName", doc.USPTGoMetadata.OriginZip.IndexName, "error", err)
+			doc.Diagnostics = append(doc.Diagnostics, types.Diagnostic{Section: types.SectionDescription, Code: types.ErrTransform.Code(), Message: err.Error()})
+			if !cfg.Lenient {
+				utils.SendErr(ctx, errChan, &types.USPTGoError{
+					Err:        err,
+					Kind:       types.ErrTransform,
+					Name:       doc.USPTGoMetadata.OriginZip.IndexName,
+					Type:       "xml translation",
+					Whence:     "translating the description to HTML",
+					DocID:      doc.Patent.Normalized.PublicationNumber,
+					ByteOffset: doc.USPTGoMetadata.OriginZip.ByteOffset,
+					ZipInfo:    doc.USPTGoMetadata.OriginZip,
+				})
 			}
+		} else {
+			doc.Patent.Description.Content = htmlDescription
 		}
-		doc.Patent.Description.Content = htmlDescription
 
-		transDocChan <- doc
+		if !utils.SendDoc(ctx, transDocChan, doc) {
+			utils.Drain(parsedXmlDocChan)
+			return
+		}
 	}
 }
@@ -1,11 +1,9 @@
 package utils
 
 import (
-	"archive/zip"
-	"bytes"
+	"bufio"
 	"errors"
 	"io"
-	"path/filepath"
 	"strings"
 
 	"github.com/diverged/uspt-go/types"
@@ -13,16 +11,18 @@ import (
 
 // TODO - Just return errors created here using constructor, then within Controller, drop them into errChan
 
-func InspectZip(zipFilePath string) (*types.USPTGoMetadata, error) {
+// InspectSource determines the file format and schema version of an opened input by peeking at its first bulk XML file.  The pipeline is chosen on this schema, while each patent document is matched to its own schema as it is split.
+// The peeked file and any XML file before it of an unrecognized schema are returned to the source, so the splitter reads every XML file and reports the documents it cannot match.
+func InspectSource(source *BulkSource) (*types.USPTGoMetadata, error) {
 
-	filename := filepath.Base(zipFilePath)
+	filename := source.Name
 
 	// If filename starts with "pftaps" then everything necessary is already known
 	if strings.HasPrefix(filename, "pftaps") {
 		return &types.USPTGoMetadata{
 			DocumentType: "grant",
 			OriginZip: types.OriginZip{
-				ZipPath:       zipFilePath,
+				ZipPath:       source.Path,
 				ZipName:       filename,
 				ZipEntryExt:   ".txt",
 				Schema:        "aps",
@@ -31,84 +31,49 @@ func InspectZip(zipFilePath string) (*types.USPTGoMetadata, error) {
 		}, nil
 	}
 
-	zipReader, err := zip.OpenReader(zipFilePath)
-	if err != nil {
-		// ! Implement error constructor and pass to channel
-		return nil, err
-	}
-	defer zipReader.Close()
-
-	type SchemaReference struct {
-		Pattern       []byte
-		DocumentType  string
-		FileExtension string
-		Schema        string
-		SchemaVersion int8
-	}
-
-	// Define the patterns to match
-	schemaReferences := []SchemaReference{
-		{Pattern: []byte("us-patent-grant-v47-2022-02-17.dtd"), SchemaVersion: 47, DocumentType: "grant", FileExtension: ".xml", Schema: "us-patent-grant-v47-2022-02-17.dtd"},
-		{Pattern: []byte("us-patent-grant-v46-2021-08-30.dtd"), SchemaVersion: 46, DocumentType: "grant", FileExtension: ".xml", Schema: "us-patent-grant-v46-2021-08-30.dtd"},
-		{Pattern: []byte("us-patent-grant-v45-2014-04-03.dtd"), SchemaVersion: 45, DocumentType: "grant", FileExtension: ".xml", Schema: "us-patent-grant-v45-2014-04-03.dtd"},
-		{Pattern: []byte("us-patent-grant-v44-2013-05-16.dtd"), SchemaVersion: 44, DocumentType: "grant", FileExtension: ".xml", Schema: "us-patent-grant-v44-2013-05-16.dtd"},
-		{Pattern: []byte("us-patent-grant-v43-2012-12-04.dtd"), SchemaVersion: 43, DocumentType: "grant", FileExtension: ".xml", Schema: "us-patent-grant-v43-2012-12-04.dtd"},
-		{Pattern: []byte("us-patent-grant-v42-2006-08-23.dtd"), SchemaVersion: 42, DocumentType: "grant", FileExtension: ".xml", Schema: "us-patent-grant-v42-2006-08-23.dtd"},
-		{Pattern: []byte("us-patent-grant-v41-2005-08-25.dtd"), SchemaVersion: 41, DocumentType: "grant", FileExtension: ".xml", Schema: "us-patent-grant-v41-2005-08-25.dtd"},
-		{Pattern: []byte("us-patent-grant-v40-2004-12-02.dtd"), SchemaVersion: 40, DocumentType: "grant", FileExtension: ".xml", Schema: "us-patent-grant-v40-2004-12-02.dtd"},
-		{Pattern: []byte("ST32-US-Grant-025xml.dtd"), SchemaVersion: 25, DocumentType: "grant", FileExtension: ".xml", Schema: "ST32-US-Grant-025xml.dtd"},
-		{Pattern: []byte("us-patent-application-v46-2022-02-17.dtd"), SchemaVersion: 46, DocumentType: "application", FileExtension: ".xml", Schema: "us-patent-application-v46-2022-02-17.dtd"},
-		{Pattern: []byte("us-patent-application-v45-2021-08-30.dtd"), SchemaVersion: 45, DocumentType: "application", FileExtension: ".xml", Schema: "us-patent-application-v45-2021-08-30.dtd"},
-		{Pattern: []byte("us-patent-application-v44-2014-04-03.dtd"), SchemaVersion: 44, DocumentType: "application", FileExtension: ".xml", Schema: "us-patent-application-v44-2014-04-03.dtd"},
-		{Pattern: []byte("us-patent-application-v43-2012-12-04.dtd"), SchemaVersion: 43, DocumentType: "application", FileExtension: ".xml", Schema: "us-patent-application-v43-2013-05-16.dtd"},
-		{Pattern: []byte("us-patent-application-v42-2006-08-23.dtd"), SchemaVersion: 42, DocumentType: "application", FileExtension: ".xml", Schema: "us-patent-application-v42-2012-12-04.dtd"},
-		{Pattern: []byte("us-patent-application-v41-2005-08-25.dtd"), SchemaVersion: 41, DocumentType: "application", FileExtension: ".xml", Schema: "us-patent-application-v41-2006-08-23.dtd"},
-		{Pattern: []byte("us-patent-application-v40-2004-12-02.dtd"), SchemaVersion: 40, DocumentType: "application", FileExtension: ".xml", Schema: "us-patent-application-v40-2005-08-25.dtd"},
-		{Pattern: []byte("pap-v16-2002-01-01.dtd"), SchemaVersion: 16, DocumentType: "application", FileExtension: ".xml", Schema: "pap-v16-2002-01-01.dtd"},
-		{Pattern: []byte("pap-v15-2001-01-31.dtd"), SchemaVersion: 15, DocumentType: "application", FileExtension: ".xml", Schema: "pap-v15-2001-01-31.dtd"},
-	}
+	var unmatched []*BulkEntry // XML files before the first of a known schema, held in memory until the source is returned
+	for {
+		entry, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err // If it can't be read, it must be skipped
+		}
 
-	for _, zipEntry := range zipReader.File {
-		// Skip directories
-		if zipEntry.FileInfo().IsDir() {
+		// Only read .xml files, ignoring .sgm files when they occasionally exist
+		if !strings.HasSuffix(strings.ToLower(entry.Name), ".xml") {
 			continue
 		}
 
-		// Only read .xml files within zip, ignoring .sgm files when they occasionally exist
-		if strings.HasSuffix(strings.ToLower(zipEntry.Name), ".xml") {
-			fileReader, err := zipEntry.Open()
-			if err != nil {
-				// ! Implement error constructor and pass to channel
-				return nil, err // If it can't be opened, it must be skipped
-			}
-			defer fileReader.Close()
-
-			// Read the first 2048 bytes of the file - more than enough to capture prolog
-			buffer := make([]byte, 2048)
-			_, err = fileReader.Read(buffer)
-			if err != nil && err != io.EOF {
-				// ! Implement error constructor and pass to channel
-				return nil, err // If it can't be read, it must be skipped
-			}
-
-			// Iterate over the schema patterns to find a match
-			for _, pattern := range schemaReferences {
-				if bytes.Contains(buffer, pattern.Pattern) {
-					return &types.USPTGoMetadata{
-						DocumentType: pattern.DocumentType,
-						OriginZip: types.OriginZip{
-							ZipPath:       zipFilePath,
-							ZipName:       filename,
-							ZipEntryExt:   pattern.FileExtension,
-							Schema:        pattern.Schema,
-							SchemaVersion: pattern.SchemaVersion,
-						},
-					}, nil
-				}
-			}
+		// Peek at the first 2048 bytes of the file - more than enough to capture prolog
+		buffer, err := entry.Reader.Peek(2048)
+		if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+			return nil, err // If it can't be read, it must be skipped
+		}
 
+		// Match the prolog against the schema registry
+		if definition, dtd, ok := types.DetectSchema(buffer); ok {
+			source.Unread(append(unmatched, entry)...)
+			return &types.USPTGoMetadata{
+				DocumentType: definition.DocumentType,
+				OriginZip: types.OriginZip{
+					ZipPath:       source.Path,
+					ZipName:       filename,
+					ZipEntryExt:   ".xml",
+					Schema:        definition.Name(),
+					SchemaVersion: definition.Version,
+					DTD:           dtd,
+				},
+			}, nil
 		}
 
+		// The source moves past this file on the next call to Next, so keep it for the splitter
+		buffered, err := BufferEntry(entry)
+		if err != nil {
+			return nil, err // If it can't be read, it must be skipped
+		}
+		unmatched = append(unmatched, buffered)
 	}
 	return nil, errors.New("failed to match a schema to zip")
 }
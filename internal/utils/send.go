@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// SendDoc sends doc on out unless ctx is done first, reporting whether it was sent.
+// Every pipeline stage sends through SendDoc or SendErr so that no goroutine is left blocked once the caller cancels.
+func SendDoc(ctx context.Context, out chan<- *types.USPTGoDoc, doc *types.USPTGoDoc) bool {
+	select {
+	case out <- doc:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// SendErr sends err on errChan unless ctx is done first, in which case the error is dropped
+func SendErr(ctx context.Context, errChan chan<- error, err error) bool {
+	select {
+	case errChan <- err:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// Drain receives and drops the documents left on in until it is closed.
+// A stage which can no longer send drains its input rather than returning, so that it closes its output only once every stage upstream of it has finished.
+func Drain(in <-chan *types.USPTGoDoc) {
+	for range in {
+	}
+}
@@ -0,0 +1,257 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// entryBufferSize comfortably exceeds the 2048 bytes peeked by InspectSource
+const entryBufferSize = 64 * 1024
+
+// BulkSource is an opened input, yielding the bulk files it holds in a single forward pass.
+// Zips, tar archives and raw XML files all become a BulkSource, so the inspection and splitting logic never deals with the container.
+type BulkSource struct {
+	Name   string // Base name of the input, e.g. "ipg240102.zip"
+	Path   string // Empty for reader inputs
+	Format types.InputFormat
+	Size   int64 // Bytes counted by BytesRead once the source is exhausted, or zero when unknown
+
+	next       func() (*BulkEntry, error)
+	pending    []*BulkEntry
+	closeEntry io.Closer // The zip entry currently being read
+	closers    []io.Closer
+	read       atomic.Int64
+}
+
+// BulkEntry is one file within a BulkSource
+type BulkEntry struct {
+	Name   string
+	Reader *bufio.Reader
+}
+
+// Next returns the next file of the source, or io.EOF once every file has been read.  Returning a new entry invalidates the previous one.
+func (s *BulkSource) Next() (*BulkEntry, error) {
+	if len(s.pending) > 0 {
+		entry := s.pending[0]
+		s.pending = s.pending[1:]
+		return entry, nil
+	}
+	if s.closeEntry != nil {
+		s.closeEntry.Close()
+		s.closeEntry = nil
+	}
+	return s.next()
+}
+
+// Unread returns peeked entries to the source, to be returned again in order by the following calls to Next.
+// Only the last entry may still be reading from the source, so any earlier one must have been buffered, see BufferEntry.
+func (s *BulkSource) Unread(entries ...*BulkEntry) {
+	s.pending = append(entries, s.pending...)
+}
+
+// BufferEntry reads the rest of an entry into memory, so it remains readable once Next has moved the source past it
+func BufferEntry(entry *BulkEntry) (*BulkEntry, error) {
+	data, err := io.ReadAll(entry.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &BulkEntry{Name: entry.Name, Reader: bufio.NewReaderSize(bytes.NewReader(data), entryBufferSize)}, nil
+}
+
+// BytesRead is the number of bytes consumed so far: the uncompressed entries of a zip, or a tar or xml file as stored.  It may be called concurrently with reading.
+func (s *BulkSource) BytesRead() int64 {
+	return s.read.Load()
+}
+
+// Close releases the source and any file it opened
+func (s *BulkSource) Close() error {
+	if s.closeEntry != nil {
+		s.closeEntry.Close()
+		s.closeEntry = nil
+	}
+	var errs []error
+	for i := len(s.closers) - 1; i >= 0; i-- {
+		errs = append(errs, s.closers[i].Close())
+	}
+	s.closers = nil
+	return errors.Join(errs...)
+}
+
+// OpenPath opens a bulk file on disk, choosing its format from the file extension
+func OpenPath(path string) (*BulkSource, error) {
+	name := filepath.Base(path)
+
+	switch format := types.InputFormatOf(name); format {
+	case types.InputZip:
+		zipReader, err := zip.OpenReader(path)
+		if err != nil {
+			return nil, err
+		}
+		source := zipSource(name, &zipReader.Reader)
+		source.Path = path
+		source.closers = append(source.closers, zipReader)
+		return source, nil
+
+	case types.InputTar, types.InputXML:
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		source, err := streamSource(name, format, f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		if info, err := f.Stat(); err == nil {
+			source.Size = info.Size()
+		}
+		source.Path = path
+		source.closers = append([]io.Closer{f}, source.closers...)
+		return source, nil
+	}
+	return nil, fmt.Errorf("%s is not a zip, tar or xml bulk file", name)
+}
+
+// OpenInput opens a bulk file supplied as a reader
+func OpenInput(input types.Input) (*BulkSource, error) {
+	format := input.Format
+	if format == "" {
+		format = types.InputFormatOf(input.Name)
+	}
+
+	switch format {
+	case types.InputZip:
+		readerAt, size := input.ReaderAt, input.Size
+		if readerAt == nil {
+			if input.Reader == nil {
+				return nil, fmt.Errorf("input %s has neither a ReaderAt nor a Reader", input.Name)
+			}
+			// zip requires random access to its central directory at the end of the file
+			data, err := io.ReadAll(input.Reader)
+			if err != nil {
+				return nil, err
+			}
+			readerAt, size = bytes.NewReader(data), int64(len(data))
+		}
+		zipReader, err := zip.NewReader(readerAt, size)
+		if err != nil {
+			return nil, err
+		}
+		return zipSource(input.Name, zipReader), nil
+
+	case types.InputTar, types.InputXML:
+		reader := input.Reader
+		if reader == nil {
+			if input.ReaderAt == nil {
+				return nil, fmt.Errorf("input %s has neither a ReaderAt nor a Reader", input.Name)
+			}
+			reader = io.NewSectionReader(input.ReaderAt, 0, input.Size)
+		}
+		source, err := streamSource(input.Name, format, reader)
+		if err != nil {
+			return nil, err
+		}
+		source.Size = input.Size
+		return source, nil
+	}
+	return nil, fmt.Errorf("input %s has no Format, and its name is not that of a zip, tar or xml bulk file", input.Name)
+}
+
+// zipSource yields the files of a zip in the order of its central directory, skipping directories
+func zipSource(name string, zipReader *zip.Reader) *BulkSource {
+	source := &BulkSource{Name: name, Format: types.InputZip}
+	files := zipReader.File
+	for _, zipEntry := range files {
+		source.Size += int64(zipEntry.UncompressedSize64)
+	}
+
+	source.next = func() (*BulkEntry, error) {
+		for len(files) > 0 {
+			zipEntry := files[0]
+			files = files[1:]
+			if zipEntry.FileInfo().IsDir() {
+				continue
+			}
+			f, err := zipEntry.Open()
+			if err != nil {
+				return nil, fmt.Errorf("opening zip entry %s: %w", zipEntry.Name, err)
+			}
+			source.closeEntry = f
+			return &BulkEntry{Name: zipEntry.Name, Reader: bufio.NewReaderSize(countingReader{f, &source.read}, entryBufferSize)}, nil
+		}
+		return nil, io.EOF
+	}
+	return source
+}
+
+// streamSource reads a tar archive or a raw XML file from start to end, decompressing it first when it begins with the gzip magic number
+func streamSource(name string, format types.InputFormat, r io.Reader) (*BulkSource, error) {
+	source := &BulkSource{Name: name, Format: format}
+
+	buffered := bufio.NewReaderSize(countingReader{r, &source.read}, entryBufferSize)
+	var reader io.Reader = buffered
+	if magic, _ := buffered.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, err
+		}
+		source.closers = append(source.closers, gzipReader)
+		reader = gzipReader
+	}
+
+	switch format {
+	case types.InputTar:
+		tarReader := tar.NewReader(reader)
+		source.next = func() (*BulkEntry, error) {
+			for {
+				header, err := tarReader.Next()
+				if err != nil {
+					return nil, err
+				}
+				// Only regular files hold bulk data
+				if header.Typeflag != tar.TypeReg {
+					continue
+				}
+				return &BulkEntry{Name: header.Name, Reader: bufio.NewReaderSize(tarReader, entryBufferSize)}, nil
+			}
+		}
+
+	default:
+		// A raw XML file is its own single entry, named without any .gz suffix
+		entryName := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".GZ")
+		entry := &BulkEntry{Name: entryName, Reader: bufio.NewReaderSize(reader, entryBufferSize)}
+		source.next = func() (*BulkEntry, error) {
+			if entry == nil {
+				return nil, io.EOF
+			}
+			next := entry
+			entry = nil
+			return next, nil
+		}
+	}
+	return source, nil
+}
+
+// countingReader adds the bytes read from r to n
+type countingReader struct {
+	r io.Reader
+	n *atomic.Int64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// BulkAPSSplitter processes a bulk source containing a bulk APS (pftaps) text file, sending each patent record to a channel.
+func BulkAPSSplitter(ctx context.Context, source *BulkSource, bulkZip *types.USPTGoMetadata, splitAPSDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
+
+	zipInfo := bulkZip.OriginZip
+
+	log.Info("BulkAPSSplitter starting for zip file", "Zip File", zipInfo.ZipName)
+
+	defer source.Close()
+
+	for {
+
+		if ctx.Err() != nil {
+			return // Stop splitting once the run is cancelled
+		}
+
+		entry, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			SendErr(ctx, errChan, &types.USPTGoError{
+				Skipped: true,
+				Name:    zipInfo.ZipName,
+				Type:    "zip entry",
+				Whence:  "attempting to open zip entry for reading",
+				Err:     err,
+				Kind:    types.ErrSplit,
+				ZipInfo: zipInfo,
+			})
+			return
+		}
+
+		// Skip anything other than the APS text file
+		if !strings.HasSuffix(strings.ToLower(entry.Name), ".txt") {
+			continue
+		}
+
+		log.Info("Starting splitting process on APS file entry inside the zip", "Zip entry within", zipInfo.ZipName)
+
+		processAPSDocument(ctx, bulkZip, entry.Name, entry.Reader, splitAPSDocChan, errChan, log)
+
+		log.Info("splitting of APS file completed, entry is now closed", "Zip Name", zipInfo.ZipName, "timestamp", time.Now())
+	}
+}
+
+// processAPSDocument splits the fixed-field APS text on each "PATN" segment header.  Lines preceding the first PATN (the file header) are discarded.
+func processAPSDocument(ctx context.Context, zipInfo *types.USPTGoMetadata, entryName string, f io.Reader, splitAPSDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
+	bufferedReader := bufio.NewReader(f)
+	var buffer bytes.Buffer
+	var inRecord bool
+	recordStart := []byte("PATN")
+	documentIndex := 0
+	var offset, recordOffset int64 // Offsets of the next line and of the record being accumulated
+
+	for {
+		line, err := bufferedReader.ReadBytes('\n')
+		if bytes.HasPrefix(line, recordStart) {
+			if inRecord {
+				if !sendAPSRecord(ctx, zipInfo, entryName, documentIndex, buffer.Bytes(), recordOffset, splitAPSDocChan, log) {
+					return
+				}
+				buffer.Reset()
+				documentIndex++
+			}
+			inRecord = true
+			recordOffset = offset
+		}
+		offset += int64(len(line))
+		if inRecord {
+			buffer.Write(line)
+		}
+		if err == io.EOF {
+			if inRecord && buffer.Len() > 0 {
+				sendAPSRecord(ctx, zipInfo, entryName, documentIndex, buffer.Bytes(), recordOffset, splitAPSDocChan, log)
+			}
+			break
+		} else if err != nil {
+			SendErr(ctx, errChan, &types.USPTGoError{
+				Skipped:    true,
+				Name:       entryName,
+				Type:       "bulk aps zip",
+				Whence:     "attempting to read zip entry",
+				Err:        err,
+				Kind:       types.ErrSplit,
+				ByteOffset: recordOffset, // The failure lies beyond the last record begun
+				ZipInfo:    zipInfo.OriginZip,
+			})
+			break
+		}
+	}
+}
+
+// sendAPSRecord reports false when the run was cancelled before the record could be sent
+func sendAPSRecord(ctx context.Context, zipInfo *types.USPTGoMetadata, entryName string, documentIndex int, record []byte, offset int64, splitAPSDocChan chan<- *types.USPTGoDoc, log types.Logger) bool {
+
+	filename := fmt.Sprintf("%s-%d.txt", strings.TrimSuffix(entryName, filepath.Ext(entryName)), documentIndex)
+
+	zipInfo.OriginZip.IndexName = filename
+	zipInfo.OriginZip.IndexInZip = documentIndex
+	zipInfo.OriginZip.ByteOffset = offset
+
+	// Copy the record out of the reusable buffer before sending
+	trimmed := bytes.TrimRight(record, "\r\n")
+	copiedRecord := make([]byte, len(trimmed))
+	copy(copiedRecord, trimmed)
+
+	sent := SendDoc(ctx, splitAPSDocChan, &types.USPTGoDoc{
+		USPTGoMetadata: *zipInfo,
+		RawSplitDoc:    copiedRecord,
+	})
+	if sent {
+		log.Debug("BulkAPSSplitter: doc => splitAPSDocChan", "filename", filename)
+	}
+	return sent
+}
@@ -1,9 +1,7 @@
 package utils
 
 import (
-	"archive/zip"
-	"bufio"
-	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -13,133 +11,138 @@ import (
 	"github.com/diverged/uspt-go/types"
 )
 
-// BulkXMLSplitter processes a zip file containing bulk XML documents, sending individual documents to a channel.
-func BulkXMLSplitter(bulkZip *types.USPTGoMetadata, splitXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
+// schemaHeadSize bounds the part of each document searched for its DOCTYPE
+const schemaHeadSize = 2048
+
+// BulkXMLSplitter processes a bulk source (zip, tar or raw XML file) containing bulk XML documents, sending individual documents to a channel.
+// It reports unreadable entries and documents of an unknown schema on errChan, which the caller must keep open until it returns, even once ctx is cancelled.
+func BulkXMLSplitter(ctx context.Context, source *BulkSource, bulkZip *types.USPTGoMetadata, splitXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
 
 	zipInfo := bulkZip.OriginZip
 
 	log.Info("BulkXMLSplitter starting for zip file", "Zip File", zipInfo.ZipName)
 
-	log.Info("Calling zip.OpenReader", "Zip File", zipInfo.ZipName)
-	zipReader, err := zip.OpenReader(zipInfo.ZipPath)
-	if err != nil {
-		log.Error("Failed to open zip file for processing", "Zip File Path", zipInfo.ZipPath, "Error", err)
-		errChan <- &types.USPTGoError{
-			Skipped: true,
-			Name:    zipInfo.ZipName,
-			Type:    "zip",
-			Whence:  "opening zip file",
-			Err:     err,
-		}
-		return
-	}
-	defer zipReader.Close()
+	defer source.Close()
 
-	for _, zipEntry := range zipReader.File {
+	for {
 
-		if zipEntry.FileInfo().IsDir() {
-			continue // Skip directories
+		if ctx.Err() != nil {
+			return // Stop splitting once the run is cancelled
 		}
 
-		log.Info("Starting splitting process on bulk file entry inside the zip", "Zip entry within", zipInfo.ZipName, "with file extension", zipInfo.ZipEntryExt)
-		f, err := zipEntry.Open()
+		entry, err := source.Next()
+		if err == io.EOF {
+			break
+		}
 		if err != nil {
-			errChan <- &types.USPTGoError{
+			SendErr(ctx, errChan, &types.USPTGoError{
 				Skipped: true,
 				Name:    zipInfo.ZipName,
 				Type:    "zip entry",
 				Whence:  "attempting to open zip entry for reading",
 				Err:     err,
-			}
-			continue
+				Kind:    types.ErrSplit,
+				ZipInfo: zipInfo,
+			})
+			return
 		}
 
-		// * Now can call processXMLDocument on the Zip Entry
-		processXMLDocument(bulkZip, zipEntry, f, splitXMLDocChan, errChan, log)
+		log.Info("Starting splitting process on bulk file entry inside the zip", "Zip entry within", zipInfo.ZipName, "with file extension", zipInfo.ZipEntryExt)
 
-		f.Close() // Close the zip entry file handle after processing
+		// * Now can call processXMLDocument on the Zip Entry
+		processXMLDocument(ctx, bulkZip, entry.Name, entry.Reader, splitXMLDocChan, errChan, log)
 
 		log.Info("splitting of bulk file completed, entry is now closed", "Zip Name", zipInfo.ZipName, "timestamp", time.Now())
 	}
 }
 
-func processXMLDocument(zipInfo *types.USPTGoMetadata, zipEntry *zip.File, f io.ReadCloser, splitXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
-	bufferedReader := bufio.NewReader(f)
-	var buffer bytes.Buffer
-	var inXMLDocument bool
-	xmlStartTag := []byte("<?xml")
+// processXMLDocument splits a bulk XML file into its documents with splitXMLStream, which finds each prolog wherever it falls in the stream
+func processXMLDocument(ctx context.Context, zipInfo *types.USPTGoMetadata, entryName string, f io.Reader, splitXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
 	documentIndex := 0 // Initialize a counter for each XML document
+	var lastOffset int64
 
-	for {
-		line, err := bufferedReader.ReadBytes('\n')
-		if bytes.Contains(line, xmlStartTag) && inXMLDocument {
-			// End of the current XML document has been reached.  Trim the trailing newline character from the buffer before sending the document.
-			trimmedBuffer := bytes.TrimSpace(buffer.Bytes())
-			sendDocument(zipInfo, zipEntry, documentIndex, bytes.NewBuffer(trimmedBuffer), splitXMLDocChan, log)
-			buffer.Reset()
-			inXMLDocument = false
-			documentIndex++ // Increment the counter for the next document
-		}
-		if bytes.Contains(line, xmlStartTag) {
-			inXMLDocument = true // Start of a new XML document
-		}
-		if inXMLDocument {
-			buffer.Write(line)
+	err := splitXMLStream(f, func(doc []byte, offset int64) bool {
+		lastOffset = offset
+		if !sendDocument(ctx, zipInfo, entryName, documentIndex, doc, offset, splitXMLDocChan, errChan, log) {
+			return false
 		}
-		if err == io.EOF {
-			if inXMLDocument {
-				// End of the last XML document in the file.  Trim the trailing newline character from the buffer before sending the document.
-				trimmedBuffer := bytes.TrimSpace(buffer.Bytes())
-				sendDocument(zipInfo, zipEntry, documentIndex, bytes.NewBuffer(trimmedBuffer), splitXMLDocChan, log)
-			}
-			break
-		} else if err != nil {
-			// log.Error("Error encountered while reading zip entry", zap.String("zipEntryName", zipEntry.Name), zap.Error(err))
-			// errChan <- err
-			errChan <- &types.USPTGoError{
-				Skipped: true,
-				Name:    zipEntry.Name,
-				Type:    "bulk xml zip",
-				Whence:  "attempting to read zip entry",
-				Err:     err,
-			}
-			break
+		documentIndex++ // Increment the counter for the next document
+		return true
+	})
+	if err != nil {
+		SendErr(ctx, errChan, &types.USPTGoError{
+			Skipped:    true,
+			Name:       entryName,
+			Type:       "bulk xml zip",
+			Whence:     "attempting to read zip entry",
+			Err:        err,
+			Kind:       types.ErrSplit,
+			ByteOffset: lastOffset, // The failure lies beyond the last document begun
+			ZipInfo:    zipInfo.OriginZip,
+		})
+	}
+}
+
+// ApplySchema matches a split patent document to its own schema, recording it in the document's metadata.  A document of an unknown schema is left as it is, and the error skipping it is returned.
+func ApplySchema(doc *types.USPTGoDoc) *types.USPTGoError {
+	head := doc.RawSplitDoc[:min(len(doc.RawSplitDoc), schemaHeadSize)]
+	definition, dtd, ok := types.DetectSchema(head)
+	if !ok {
+		return &types.USPTGoError{
+			Err:         fmt.Errorf("unrecognized document schema %q", dtd),
+			Kind:        types.ErrUnknownSchema,
+			Skipped:     true,
+			Name:        doc.USPTGoMetadata.OriginZip.IndexName,
+			Type:        "xml patent",
+			Whence:      "detecting the document schema",
+			ByteOffset:  doc.USPTGoMetadata.OriginZip.ByteOffset,
+			ZipInfo:     doc.USPTGoMetadata.OriginZip,
+			RawSplitDoc: doc.RawSplitDoc,
 		}
 	}
+	doc.USPTGoMetadata.DocumentType = definition.DocumentType
+	doc.USPTGoMetadata.OriginZip.Schema = definition.Name()
+	doc.USPTGoMetadata.OriginZip.SchemaVersion = definition.Version
+	doc.USPTGoMetadata.OriginZip.DTD = dtd
+	return nil
 }
 
-func sendDocument(zipInfo *types.USPTGoMetadata, zipEntry *zip.File, documentIndex int, buffer *bytes.Buffer,
-	splitXMLDocChan chan<- *types.USPTGoDoc, log types.Logger) {
+// sendDocument reports false when the run was cancelled before the document could be sent
+// Patent documents are matched to their own schema, as bulk files spanning a DTD revision mix schemas.  A document of an unknown schema is reported and skipped.
+func sendDocument(ctx context.Context, zipInfo *types.USPTGoMetadata, entryName string, documentIndex int, rawDoc []byte, offset int64,
+	splitXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) bool {
 
-	filename := fmt.Sprintf("%s-%d.xml", strings.TrimSuffix(zipEntry.Name, filepath.Ext(zipEntry.Name)), documentIndex)
+	filename := fmt.Sprintf("%s-%d.xml", strings.TrimSuffix(entryName, filepath.Ext(entryName)), documentIndex)
 
 	zipInfo.OriginZip.IndexName = filename
+	zipInfo.OriginZip.IndexInZip = documentIndex
+	zipInfo.OriginZip.ByteOffset = offset
 
 	// Simple indicator of []byte slice integrity on way out
-	if buffer.Bytes()[len(buffer.Bytes())-1] != '>' {
+	if len(rawDoc) == 0 || rawDoc[len(rawDoc)-1] != '>' {
 		log.Error("Document does not end with a closing tag", "filename", filename)
 	}
 
-	// Create a deep copy of the buffer to send through the channel
-	originalXML := buffer.Bytes()
-	copiedXML := make([]byte, len(originalXML)) // instantiate the new variable with a byte slice sized to match the source byte slice being copied
-	copy(copiedXML, originalXML)                // Send 'copiedXML' through the channel
+	// Create a deep copy of the pooled buffer to send through the channel
+	copiedXML := make([]byte, len(rawDoc)) // instantiate the new variable with a byte slice sized to match the source byte slice being copied
+	copy(copiedXML, rawDoc)                // Send 'copiedXML' through the channel
+
+	doc := &types.USPTGoDoc{
+		USPTGoMetadata: *zipInfo,
+		RawSplitDoc:    copiedXML,
+	}
+	if zipInfo.DocumentType == "trademark" {
+		doc.Trademark.RawSplitDoc = copiedXML
+	} else if schemaErr := ApplySchema(doc); schemaErr != nil {
+		log.Warn("Document schema not recognized", "filename", filename, "error", schemaErr)
+		return SendErr(ctx, errChan, schemaErr)
+	}
 
 	// Send the document to the splitXMLDocChan
-	switch zipInfo.DocumentType {
-	case "trademark":
-		splitXMLDocChan <- &types.USPTGoDoc{
-			USPTGoMetadata: *zipInfo,
-			Trademark: types.Trademark{
-				RawSplitDoc: copiedXML,
-			},
-		}
-	default:
-		splitXMLDocChan <- &types.USPTGoDoc{
-			USPTGoMetadata: *zipInfo,
-			RawSplitDoc:    copiedXML,
-		}
+	if !SendDoc(ctx, splitXMLDocChan, doc) {
+		return false
 	}
 
 	log.Debug("BulkXMLSplitter: doc => splitXMLDocChan", "filename", filename)
+	return true
 }
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// BulkTrademarkSplitter processes a bulk source containing a trademark daily XML file, sending each <case-file> to a channel.
+// Unlike the patent bulk files, a trademark daily file is a single XML document, so it is split on elements rather than prologs.
+func BulkTrademarkSplitter(ctx context.Context, source *BulkSource, bulkZip *types.USPTGoMetadata, splitXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
+
+	zipInfo := bulkZip.OriginZip
+
+	log.Info("BulkTrademarkSplitter starting for zip file", "Zip File", zipInfo.ZipName)
+
+	defer source.Close()
+
+	for {
+
+		if ctx.Err() != nil {
+			return // Stop splitting once the run is cancelled
+		}
+
+		entry, err := source.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			SendErr(ctx, errChan, &types.USPTGoError{
+				Skipped: true,
+				Name:    zipInfo.ZipName,
+				Type:    "zip entry",
+				Whence:  "attempting to open zip entry for reading",
+				Err:     err,
+				Kind:    types.ErrSplit,
+				ZipInfo: zipInfo,
+			})
+			return
+		}
+
+		log.Info("Starting splitting process on trademark file entry inside the zip", "Zip entry within", zipInfo.ZipName)
+
+		processTrademarkDocument(ctx, bulkZip, entry.Name, entry.Reader, splitXMLDocChan, errChan, log)
+
+		log.Info("splitting of trademark file completed, entry is now closed", "Zip Name", zipInfo.ZipName, "timestamp", time.Now())
+	}
+}
+
+func processTrademarkDocument(ctx context.Context, zipInfo *types.USPTGoMetadata, entryName string, f io.Reader, splitXMLDocChan chan<- *types.USPTGoDoc, errChan chan<- error, log types.Logger) {
+	reader := &recordingReader{r: bufio.NewReader(f)}
+	decoder := xml.NewDecoder(reader) // Reads reader byte by byte, so reader holds exactly the bytes behind each token
+	documentIndex := 0
+
+	for {
+		reader.mark(decoder.InputOffset())
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			SendErr(ctx, errChan, &types.USPTGoError{
+				Skipped:    true,
+				Name:       entryName,
+				Type:       "bulk trademark zip",
+				Whence:     "attempting to read zip entry",
+				Err:        err,
+				Kind:       types.ErrSplit,
+				ByteOffset: decoder.InputOffset(), // The decoder stopped at the failure
+				ZipInfo:    zipInfo.OriginZip,
+			})
+			break
+		}
+
+		startElement, ok := token.(xml.StartElement)
+		if !ok || startElement.Name.Local != "case-file" {
+			continue
+		}
+
+		// The start tag follows any whitespace or markup read along with it
+		offset := reader.base + int64(bytes.LastIndexByte(reader.buf[:decoder.InputOffset()-reader.base], '<'))
+
+		if err := decoder.Skip(); err != nil {
+			SendErr(ctx, errChan, &types.USPTGoError{
+				Skipped:    true,
+				Name:       entryName,
+				Type:       "bulk trademark zip",
+				Whence:     "attempting to split case-file element",
+				Err:        err,
+				Kind:       types.ErrSplit,
+				ByteOffset: offset, // The offset of the case-file which could not be split
+				ZipInfo:    zipInfo.OriginZip,
+			})
+			break
+		}
+
+		// The case-file exactly as it appears in the bulk file, attributes and namespace declarations included
+		rawDoc := reader.buf[offset-reader.base : decoder.InputOffset()-reader.base]
+
+		if !sendDocument(ctx, zipInfo, entryName, documentIndex, rawDoc, offset, splitXMLDocChan, errChan, log) {
+			return
+		}
+		documentIndex++
+	}
+}
+
+// recordingReader keeps the bytes read from r since the last mark, so that a split case-file can be sliced from the bulk file as is
+type recordingReader struct {
+	r    *bufio.Reader
+	buf  []byte
+	base int64 // The offset of buf[0] within r
+}
+
+// mark drops the bytes recorded before offset, which must not be past the bytes read
+func (r *recordingReader) mark(offset int64) {
+	kept := copy(r.buf, r.buf[offset-r.base:])
+	r.buf = r.buf[:kept]
+	r.base = offset
+}
+
+func (r *recordingReader) ReadByte() (byte, error) {
+	b, err := r.r.ReadByte()
+	if err == nil {
+		r.buf = append(r.buf, b)
+	}
+	return b, err
+}
+
+func (r *recordingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.buf = append(r.buf, p[:n]...)
+	return n, err
+}
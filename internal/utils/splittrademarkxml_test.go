@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// mockLogger implements the Logger interface for testing purposes.
+type mockLogger struct{}
+
+func (m *mockLogger) Debug(msg string, keysAndValues ...interface{}) {}
+func (m *mockLogger) Info(msg string, keysAndValues ...interface{})  {}
+func (m *mockLogger) Warn(msg string, keysAndValues ...interface{})  {}
+func (m *mockLogger) Error(msg string, keysAndValues ...interface{}) {}
+
+func TestProcessTrademarkDocument(t *testing.T) {
+	first := `<case-file xmlns:tm="urn:example:tm" status="live"><serial-number>97000001</serial-number><tm:note>a &amp; b</tm:note></case-file>`
+	second := `<case-file><serial-number>97000002</serial-number></case-file>`
+	daily := "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<trademark-applications-daily>\n<application-information>\n<file-segments><action-keys>\n  " +
+		first + "\n  " + second + "\n</action-keys></file-segments>\n</application-information>\n</trademark-applications-daily>\n"
+
+	docChan := make(chan *types.USPTGoDoc, 10)
+	errChan := make(chan error, 10)
+	zipInfo := &types.USPTGoMetadata{DocumentType: "trademark", OriginZip: types.OriginZip{ZipName: "apc240102.zip"}}
+	processTrademarkDocument(context.Background(), zipInfo, "apc240102.xml", strings.NewReader(daily), docChan, errChan, &mockLogger{})
+	close(docChan)
+	close(errChan)
+
+	for err := range errChan {
+		t.Errorf("unexpected error: %v", err)
+	}
+	var docs []*types.USPTGoDoc
+	for doc := range docChan {
+		docs = append(docs, doc)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 case-files, got %d", len(docs))
+	}
+	for i, want := range []string{first, second} {
+		doc := docs[i]
+		if string(doc.RawSplitDoc) != want || string(doc.Trademark.RawSplitDoc) != want {
+			t.Errorf("case-file %d: expected the bytes of the bulk file, got %q", i, doc.RawSplitDoc)
+		}
+		if offset := doc.USPTGoMetadata.OriginZip.ByteOffset; offset != int64(strings.Index(daily, want)) {
+			t.Errorf("case-file %d: expected the offset of its start tag %d, got %d", i, strings.Index(daily, want), offset)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// scanChunkSize is the size of each read from the underlying stream
+const scanChunkSize = 256 * 1024
+
+// Markers recognized by splitXMLStream.  A prolog marker is only a boundary when followed by whitespace, which excludes processing instructions such as <?xml-stylesheet.
+var (
+	prologStart  = []byte("<?xml")
+	commentStart = []byte("<!--")
+	commentEnd   = []byte("-->")
+	cdataStart   = []byte("<![CDATA[")
+	cdataEnd     = []byte("]]>")
+)
+
+// longestMarker is the number of bytes needed to recognize any marker, plus the whitespace following a prolog
+const longestMarker = 9
+
+// docBufferPool holds the buffers documents are accumulated in, shared by every splitter of the run
+var docBufferPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+type scanState int
+
+const (
+	scanMarkup scanState = iota
+	scanComment
+	scanCDATA
+)
+
+// splitXMLStream splits a stream of concatenated XML documents on their prologs, wherever they fall, calling emit with each document and the byte offset of its prolog within the stream.
+// Prologs inside comments and CDATA sections are ignored, and anything before the first prolog is discarded.  The slice passed to emit is only valid until emit returns, which reports whether to continue.
+func splitXMLStream(r io.Reader, emit func(doc []byte, offset int64) bool) error {
+
+	doc := docBufferPool.Get().(*bytes.Buffer)
+	doc.Reset()
+	defer docBufferPool.Put(doc)
+
+	var (
+		chunk     = make([]byte, scanChunkSize+longestMarker)
+		carry     int   // Bytes at the start of chunk held back from the previous read, as they may begin a marker
+		base      int64 // Stream offset of chunk[0]
+		docOffset int64 = -1
+		state     scanState
+	)
+
+	for {
+		n, readErr := io.ReadFull(r, chunk[carry:carry+scanChunkSize])
+		data := chunk[:carry+n]
+		atEOF := readErr == io.EOF || readErr == io.ErrUnexpectedEOF
+		if readErr != nil && !atEOF {
+			return readErr
+		}
+
+		// Markers are only searched for where they fit entirely within data, unless the stream has ended
+		limit := len(data)
+		if !atEOF {
+			limit = max(len(data)-longestMarker, 0)
+		}
+
+		start, pos := 0, 0 // data[start:pos] belongs to the current document
+		for pos < limit {
+			switch state {
+			case scanComment, scanCDATA:
+				end := commentEnd
+				if state == scanCDATA {
+					end = cdataEnd
+				}
+				i := bytes.Index(data[pos:], end)
+				if i < 0 || pos+i >= limit {
+					pos = limit
+					continue
+				}
+				pos += i + len(end)
+				state = scanMarkup
+
+			default:
+				i := bytes.IndexByte(data[pos:limit], '<')
+				if i < 0 {
+					pos = limit
+					continue
+				}
+				pos += i
+				rest := data[pos:]
+				switch {
+				case bytes.HasPrefix(rest, prologStart) && (len(rest) == len(prologStart) || isXMLSpace(rest[len(prologStart)])):
+					if docOffset >= 0 {
+						doc.Write(data[start:pos])
+						if !emit(bytes.TrimSpace(doc.Bytes()), docOffset) {
+							return nil
+						}
+					}
+					doc.Reset()
+					start, docOffset = pos, base+int64(pos)
+					pos += len(prologStart)
+				case bytes.HasPrefix(rest, commentStart):
+					state = scanComment
+					pos += len(commentStart)
+				case bytes.HasPrefix(rest, cdataStart):
+					state = scanCDATA
+					pos += len(cdataStart)
+				default:
+					pos++
+				}
+			}
+		}
+
+		// A marker may end beyond limit, in which case pos has passed it
+		if pos > limit {
+			limit = pos
+		}
+		if docOffset >= 0 {
+			doc.Write(data[start:limit])
+		}
+
+		if atEOF {
+			if docOffset >= 0 && doc.Len() > 0 {
+				emit(bytes.TrimSpace(doc.Bytes()), docOffset)
+			}
+			return nil
+		}
+
+		// Hold back the unscanned tail, which may begin a marker
+		carry = copy(chunk, data[limit:])
+		base += int64(limit)
+	}
+}
+
+func isXMLSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
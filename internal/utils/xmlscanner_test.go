@@ -0,0 +1,206 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func splitAll(t *testing.T, r io.Reader) (docs []string, offsets []int64) {
+	t.Helper()
+	err := splitXMLStream(r, func(doc []byte, offset int64) bool {
+		docs = append(docs, string(doc))
+		offsets = append(offsets, offset)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("splitXMLStream returned an error: %v", err)
+	}
+	return docs, offsets
+}
+
+func TestSplitXMLStream(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "Prologs at line starts",
+			input: "<?xml version=\"1.0\"?>\n<a>1</a>\n<?xml version=\"1.0\"?>\n<b>2</b>\n",
+			want:  []string{"<?xml version=\"1.0\"?>\n<a>1</a>", "<?xml version=\"1.0\"?>\n<b>2</b>"},
+		},
+		{
+			name:  "Prolog mid-line",
+			input: "<?xml version=\"1.0\"?><a>1</a><?xml version=\"1.0\"?><b>2</b>",
+			want:  []string{"<?xml version=\"1.0\"?><a>1</a>", "<?xml version=\"1.0\"?><b>2</b>"},
+		},
+		{
+			name:  "Prolog text in CDATA",
+			input: "<?xml version=\"1.0\"?>\n<a><![CDATA[\n<?xml version=\"1.0\"?> ]] ]]></a>\n<?xml version=\"1.0\"?>\n<b/>",
+			want:  []string{"<?xml version=\"1.0\"?>\n<a><![CDATA[\n<?xml version=\"1.0\"?> ]] ]]></a>", "<?xml version=\"1.0\"?>\n<b/>"},
+		},
+		{
+			name:  "Prolog text in a comment",
+			input: "<?xml version=\"1.0\"?>\n<!-- an example:\n<?xml version=\"1.0\"?> -->\n<a/>",
+			want:  []string{"<?xml version=\"1.0\"?>\n<!-- an example:\n<?xml version=\"1.0\"?> -->\n<a/>"},
+		},
+		{
+			name:  "Stylesheet processing instruction",
+			input: "<?xml version=\"1.0\"?>\n<?xml-stylesheet href=\"a.xsl\"?>\n<a/>",
+			want:  []string{"<?xml version=\"1.0\"?>\n<?xml-stylesheet href=\"a.xsl\"?>\n<a/>"},
+		},
+		{
+			name:  "Leading junk is discarded",
+			input: "header\n<?xml version=\"1.0\"?><a/>",
+			want:  []string{"<?xml version=\"1.0\"?><a/>"},
+		},
+		{
+			name:  "No prolog",
+			input: "<a/>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A one byte reader places every marker across a read boundary
+			for _, r := range []io.Reader{strings.NewReader(tt.input), iotest.OneByteReader(strings.NewReader(tt.input))} {
+				docs, _ := splitAll(t, r)
+				if fmt.Sprintf("%q", docs) != fmt.Sprintf("%q", tt.want) {
+					t.Errorf("got %q, want %q", docs, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitXMLStreamOffsets(t *testing.T) {
+	// Documents larger than a chunk, so boundaries fall at arbitrary points of the reads
+	var input bytes.Buffer
+	var wantOffsets []int64
+	for i := 0; i < 5; i++ {
+		wantOffsets = append(wantOffsets, int64(input.Len()))
+		fmt.Fprintf(&input, "<?xml version=\"1.0\"?><doc n=\"%d\">%s<![CDATA[<?xml version=\"1.0\"?>]]></doc>", i, strings.Repeat("x", scanChunkSize/2+i*7919))
+	}
+
+	docs, offsets := splitAll(t, bytes.NewReader(input.Bytes()))
+	if len(docs) != 5 {
+		t.Fatalf("expected 5 documents, got %d", len(docs))
+	}
+	for i, doc := range docs {
+		if offsets[i] != wantOffsets[i] {
+			t.Errorf("document %d: offset %d, want %d", i, offsets[i], wantOffsets[i])
+		}
+		if !strings.HasPrefix(doc, fmt.Sprintf("<?xml version=\"1.0\"?><doc n=\"%d\">", i)) || !strings.HasSuffix(doc, "</doc>") {
+			t.Errorf("document %d was split incorrectly", i)
+		}
+	}
+}
+
+func TestSplitXMLStreamStops(t *testing.T) {
+	var count int
+	err := splitXMLStream(strings.NewReader(strings.Repeat("<?xml version=\"1.0\"?><a/>", 10)), func(doc []byte, offset int64) bool {
+		count++
+		return count < 3
+	})
+	if err != nil || count != 3 {
+		t.Errorf("expected to stop after 3 documents, got %d and %v", count, err)
+	}
+}
+
+// lineSplitXMLStream is the line based splitter which splitXMLStream replaced, kept to benchmark against
+func lineSplitXMLStream(r io.Reader, emit func(doc []byte, offset int64) bool) error {
+	bufferedReader := bufio.NewReader(r)
+	var buffer bytes.Buffer
+	var inXMLDocument bool
+	xmlStartTag := []byte("<?xml")
+
+	for {
+		line, err := bufferedReader.ReadBytes('\n')
+		if bytes.Contains(line, xmlStartTag) && inXMLDocument {
+			if !emit(bytes.TrimSpace(buffer.Bytes()), 0) {
+				return nil
+			}
+			buffer.Reset()
+			inXMLDocument = false
+		}
+		if bytes.Contains(line, xmlStartTag) {
+			inXMLDocument = true
+		}
+		if inXMLDocument {
+			buffer.Write(line)
+		}
+		if err == io.EOF {
+			if inXMLDocument {
+				emit(bytes.TrimSpace(buffer.Bytes()), 0)
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// syntheticBulk streams size bytes of concatenated grant documents without holding them in memory
+type syntheticBulk struct {
+	doc       []byte
+	pos       int
+	remaining int64
+}
+
+func newSyntheticBulk(size int64) *syntheticBulk {
+	var doc bytes.Buffer
+	doc.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n<!DOCTYPE us-patent-grant SYSTEM \"us-patent-grant-v45-2014-04-03.dtd\" [ ]>\n<us-patent-grant lang=\"EN\" dtd-version=\"v4.5 2014-04-03\">\n")
+	for i := 0; i < 400; i++ {
+		fmt.Fprintf(&doc, "<p id=\"p-%04d\" num=\"%04d\">The widget <b>comprises</b> a housing, a shaft and a bearing supporting the shaft within the housing.</p>\n", i, i)
+	}
+	doc.WriteString("</us-patent-grant>\n")
+	return &syntheticBulk{doc: doc.Bytes(), remaining: size}
+}
+
+func (s *syntheticBulk) Read(p []byte) (int, error) {
+	if s.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > s.remaining {
+		p = p[:s.remaining]
+	}
+	n := 0
+	for n < len(p) {
+		copied := copy(p[n:], s.doc[s.pos:])
+		n += copied
+		s.pos = (s.pos + copied) % len(s.doc)
+	}
+	s.remaining -= int64(n)
+	return n, nil
+}
+
+// benchmarkBulkSize is the size of the synthetic bulk file split by each benchmark iteration
+const benchmarkBulkSize = 1 << 30
+
+func benchmarkSplitter(b *testing.B, split func(io.Reader, func([]byte, int64) bool) error) {
+	b.SetBytes(benchmarkBulkSize)
+	for i := 0; i < b.N; i++ {
+		var docs int
+		err := split(newSyntheticBulk(benchmarkBulkSize), func(doc []byte, offset int64) bool {
+			docs++
+			return true
+		})
+		if err != nil || docs == 0 {
+			b.Fatalf("split %d documents, error %v", docs, err)
+		}
+	}
+}
+
+// Run with: go test ./internal/utils -run '^$' -bench Split -benchtime 1x -benchmem
+func BenchmarkSplitXMLStream(b *testing.B) {
+	benchmarkSplitter(b, splitXMLStream)
+}
+
+func BenchmarkLineSplitXMLStream(b *testing.B) {
+	benchmarkSplitter(b, lineSplitXMLStream)
+}
@@ -0,0 +1,21 @@
+//go:build ignore
+
+// gen_schema writes record.schema.json from the Go types of the output package
+package main
+
+import (
+	"log"
+	"os"
+
+	"github.com/diverged/uspt-go/output"
+)
+
+func main() {
+	schema, err := output.JSONSchema()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("record.schema.json", schema, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
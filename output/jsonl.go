@@ -0,0 +1,183 @@
+package output
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// Compression selects how the output is compressed
+type Compression int
+
+const (
+	CompressNone Compression = iota
+	CompressGzip
+	CompressZstd
+)
+
+// Ext is the file extension of the compression, e.g. ".gz", to follow ".jsonl"
+func (c Compression) Ext() string {
+	switch c {
+	case CompressGzip:
+		return ".gz"
+	case CompressZstd:
+		return ".zst"
+	}
+	return ""
+}
+
+// JSONLOptions configures a JSONLWriter.  Zero values disable compression and rotation.
+type JSONLOptions struct {
+	Compression  Compression
+	MaxDocuments int   // Rotate to a new part after this many records.  Requires NewRotatingJSONLWriter.
+	MaxBytes     int64 // Rotate to a new part once this many uncompressed bytes are written.  Requires NewRotatingJSONLWriter.
+}
+
+// JSONLWriter writes each USPTGoDoc as a line of JSON holding its Record, optionally compressed and rotated across several parts.  It is safe for concurrent use.
+type JSONLWriter struct {
+	opts   JSONLOptions
+	create func(part int) (io.WriteCloser, error)
+
+	mu        sync.Mutex
+	part      int // Number of parts created so far
+	out       io.WriteCloser
+	enc       io.WriteCloser // The compressor, or nil
+	buf       *bufio.Writer
+	documents int // Written to the current part
+	bytes     int64
+}
+
+// NewJSONLWriter writes every record to w, which is left open by Close.  The rotation options are ignored.
+func NewJSONLWriter(w io.Writer, opts JSONLOptions) *JSONLWriter {
+	opts.MaxDocuments, opts.MaxBytes = 0, 0
+	return NewRotatingJSONLWriter(func(part int) (io.WriteCloser, error) {
+		return nopCloser{w}, nil
+	}, opts)
+}
+
+// NewRotatingJSONLWriter writes to the parts returned by create, numbered from 1, starting a new part whenever MaxDocuments or MaxBytes is reached.  A part is only created once a record is written to it.
+func NewRotatingJSONLWriter(create func(part int) (io.WriteCloser, error), opts JSONLOptions) *JSONLWriter {
+	return &JSONLWriter{opts: opts, create: create}
+}
+
+// CreateFiles returns a create function for NewRotatingJSONLWriter which creates files named by pattern, a path with a single integer verb such as "out/ipg240102-%03d.jsonl.gz"
+func CreateFiles(pattern string) func(part int) (io.WriteCloser, error) {
+	return func(part int) (io.WriteCloser, error) {
+		path := fmt.Sprintf(pattern, part)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		return os.Create(path)
+	}
+}
+
+// Write writes the Record of doc
+func (w *JSONLWriter) Write(doc *types.USPTGoDoc) error {
+	return w.WriteRecord(NewRecord(doc))
+}
+
+// WriteRecord writes record, e.g. after it has been amended by the caller
+func (w *JSONLWriter) WriteRecord(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.out != nil && w.full() {
+		if err := w.closePart(); err != nil {
+			return err
+		}
+	}
+	if w.out == nil {
+		if err := w.openPart(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := w.buf.Write(line); err != nil {
+		return err
+	}
+	w.documents++
+	w.bytes += int64(len(line))
+	return nil
+}
+
+// Parts is the number of parts created so far
+func (w *JSONLWriter) Parts() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.part
+}
+
+// Close flushes the current part and closes it
+func (w *JSONLWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.out == nil {
+		return nil
+	}
+	return w.closePart()
+}
+
+// full reports whether the current part has reached its limits
+func (w *JSONLWriter) full() bool {
+	return (w.opts.MaxDocuments > 0 && w.documents >= w.opts.MaxDocuments) ||
+		(w.opts.MaxBytes > 0 && w.bytes >= w.opts.MaxBytes)
+}
+
+func (w *JSONLWriter) openPart() error {
+	out, err := w.create(w.part + 1)
+	if err != nil {
+		return err
+	}
+	w.part++
+	w.out, w.documents, w.bytes = out, 0, 0
+
+	var dst io.Writer = out
+	switch w.opts.Compression {
+	case CompressGzip:
+		w.enc = gzip.NewWriter(out)
+		dst = w.enc
+	case CompressZstd:
+		enc, err := zstd.NewWriter(out)
+		if err != nil {
+			out.Close()
+			w.out = nil
+			return err
+		}
+		w.enc = enc
+		dst = enc
+	}
+	w.buf = bufio.NewWriterSize(dst, 64*1024)
+	return nil
+}
+
+func (w *JSONLWriter) closePart() error {
+	err := w.buf.Flush()
+	if w.enc != nil {
+		err = errors.Join(err, w.enc.Close())
+	}
+	err = errors.Join(err, w.out.Close())
+	w.out, w.enc, w.buf = nil, nil, nil
+	return err
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,218 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	usptgo "github.com/diverged/uspt-go"
+	"github.com/diverged/uspt-go/types"
+)
+
+const testGrantTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE us-patent-grant SYSTEM "us-patent-grant-v45-2014-04-03.dtd" [ ]>
+<us-patent-grant lang="EN" dtd-version="v4.5 2014-04-03" file="US%[1]d-20180619.XML" status="PRODUCTION" id="us-patent-grant" country="US" date-produced="20180605" date-publ="20180619">
+<us-bibliographic-data-grant>
+<publication-reference><document-id><country>US</country><doc-number>%[1]d</doc-number><kind>B2</kind><date>20180619</date></document-id></publication-reference>
+<application-reference appl-type="utility"><document-id><country>US</country><doc-number>14643719</doc-number><date>20150310</date></document-id></application-reference>
+<classifications-cpc>
+<main-cpc><classification-cpc><cpc-version-indicator><date>20130101</date></cpc-version-indicator><section>B</section><class>29</class><subclass>C</subclass><main-group>45</main-group><subgroup>1775</subgroup><symbol-position>F</symbol-position><classification-value>I</classification-value></classification-cpc></main-cpc>
+</classifications-cpc>
+<classification-national><country>US</country><main-classification>425542</main-classification></classification-national>
+<invention-title id="d2e43">Widget <i>in situ</i> %[1]d</invention-title>
+<us-references-cited>
+<us-citation><patcit num="00001"><document-id><country>US</country><doc-number>4828475</doc-number><kind>A</kind><date>19890500</date></document-id></patcit><category>cited by examiner</category></us-citation>
+</us-references-cited>
+<number-of-claims>2</number-of-claims>
+<us-parties>
+<us-applicants><us-applicant sequence="001" app-type="applicant" designation="us-only"><addressbook><orgname>Widget Corp.</orgname><address><city>Tokyo</city><country>JP</country></address></addressbook><residence><country>JP</country></residence></us-applicant></us-applicants>
+<inventors><inventor sequence="001" designation="us-only"><addressbook><last-name>Smith</last-name><first-name>Jane</first-name><address><city>Tokyo</city><country>JP</country></address></addressbook></inventor></inventors>
+</us-parties>
+</us-bibliographic-data-grant>
+<abstract id="abstract"><p id="p-0001" num="0000">A widget.</p></abstract>
+<description id="description"><p id="p-0002" num="0001">Text.</p></description>
+<claims id="claims">
+<claim id="CLM-00001" num="00001"><claim-text>1. A widget.</claim-text></claim>
+<claim id="CLM-00002" num="00002"><claim-text>2. The widget of <claim-ref idref="CLM-00001">claim 1</claim-ref>, which is blue.</claim-text></claim>
+</claims>
+</us-patent-grant>
+`
+
+// testDocs parses n grant documents through the pipeline
+func testDocs(t *testing.T, n int) []*types.USPTGoDoc {
+	t.Helper()
+
+	var bulk strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&bulk, testGrantTemplate, 10000000+i)
+	}
+
+	var docs []*types.USPTGoDoc
+	cfg := &types.USPTGoConfig{Inputs: []types.Input{{Name: "ipg180619.xml", Reader: strings.NewReader(bulk.String())}}}
+	if err := usptgo.Process(context.Background(), cfg, func(doc *types.USPTGoDoc) error {
+		docs = append(docs, doc)
+		return nil
+	}); err != nil {
+		t.Fatalf("Process returned an error: %v", err)
+	}
+	if len(docs) != n {
+		t.Fatalf("expected %d documents, got %d", n, len(docs))
+	}
+	return docs
+}
+
+func TestNewRecord(t *testing.T) {
+	doc := testDocs(t, 1)[0]
+
+	line, err := json.Marshal(NewRecord(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record Record
+	if err := json.Unmarshal(line, &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record.SchemaVersion != SchemaVersion || record.DocumentType != "grant" || record.Origin.ZipName != "ipg180619.xml" || record.Trademark != nil {
+		t.Errorf("unexpected record header: %+v", record)
+	}
+	patent := record.Patent
+	if patent == nil {
+		t.Fatal("expected a patent")
+	}
+	if patent.PublicationNumber != "US10000000B2" || patent.PublicationDate != "2018-06-19" || patent.ApplicationNumber != "US14643719" || patent.ApplicationDate != "2015-03-10" {
+		t.Errorf("unexpected identifiers: %+v", patent)
+	}
+	if patent.Title != "Widget in situ 10000000" {
+		t.Errorf("expected a plain text title, got %q", patent.Title)
+	}
+	if len(patent.Classifications.CPC) != 1 || patent.Classifications.CPC[0].Symbol != "B29C 45/1775" || patent.USClassification == nil || patent.USClassification.Main != "425542" {
+		t.Errorf("unexpected classifications: %+v, %+v", patent.Classifications, patent.USClassification)
+	}
+	if len(patent.Parties.Applicants) != 1 || len(patent.Parties.Inventors) != 1 || patent.Parties.Inventors[0].LastName != "Smith" {
+		t.Errorf("unexpected parties: %+v", patent.Parties)
+	}
+	if len(patent.Citations.Patent) != 1 || patent.Abstract == "" || patent.Description == "" || patent.Claims == "" {
+		t.Errorf("unexpected citations or text: %+v", patent)
+	}
+
+	claims := patent.StructuredClaims
+	if len(claims) != 2 || claims[0].Type != "independent" || claims[1].Type != "dependent" ||
+		fmt.Sprint(claims[1].ParentIDs) != "[CLM-00001]" || fmt.Sprint(claims[0].ChildIDs) != "[CLM-00002]" || claims[1].Level != 1 {
+		t.Errorf("unexpected structured claims: %+v", claims)
+	}
+}
+
+func TestJSONLWriterRotation(t *testing.T) {
+	docs := testDocs(t, 5)
+
+	tests := []struct {
+		name        string
+		opts        JSONLOptions
+		partLengths []int
+	}{
+		{"By document count", JSONLOptions{Compression: CompressGzip, MaxDocuments: 2}, []int{2, 2, 1}},
+		{"By size", JSONLOptions{Compression: CompressZstd, MaxBytes: 1}, []int{1, 1, 1, 1, 1}},
+		{"Unlimited", JSONLOptions{}, []int{5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pattern := filepath.Join(t.TempDir(), "out", "ipg180619-%03d.jsonl"+tt.opts.Compression.Ext())
+			w := NewRotatingJSONLWriter(CreateFiles(pattern), tt.opts)
+			for _, doc := range docs {
+				if err := w.Write(doc); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if w.Parts() != len(tt.partLengths) {
+				t.Fatalf("expected %d parts, got %d", len(tt.partLengths), w.Parts())
+			}
+
+			var numbers []string
+			for part, length := range tt.partLengths {
+				records := readRecords(t, fmt.Sprintf(pattern, part+1), tt.opts.Compression)
+				if len(records) != length {
+					t.Errorf("part %d: expected %d records, got %d", part+1, length, len(records))
+				}
+				for _, record := range records {
+					numbers = append(numbers, record.Patent.PublicationNumber)
+				}
+			}
+			if len(numbers) != 5 || numbers[0] != "US10000000B2" || numbers[4] != "US10000004B2" {
+				t.Errorf("expected the documents in order, got %v", numbers)
+			}
+		})
+	}
+}
+
+func TestJSONLWriterStream(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewJSONLWriter(&buf, JSONLOptions{MaxDocuments: 1})
+	for _, doc := range testDocs(t, 3) {
+		if err := w.Write(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 || w.Parts() != 1 {
+		t.Errorf("expected 3 lines in a single part, got %d lines in %d parts", lines, w.Parts())
+	}
+}
+
+func readRecords(t *testing.T, path string, compression Compression) []Record {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	switch compression {
+	case CompressGzip:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r = gz
+	case CompressZstd:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
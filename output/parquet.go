@@ -0,0 +1,406 @@
+package output
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// ParquetSchemaVersion is the version of the Parquet tables, recorded in the key-value metadata of every file.
+// Within a major version columns are only ever added, as optional columns, and never renamed, retyped or removed, so the files of different library versions can be read together, e.g. with DuckDB's union_by_name or Spark's mergeSchema.
+const ParquetSchemaVersion = "1.0"
+
+// The tables written by a ParquetWriter.  Every child table is keyed to the patents table by publication_number.
+const (
+	TablePatents         = "patents"
+	TableClaims          = "claims"
+	TableClassifications = "classifications"
+	TableParties         = "parties"
+	TableCitations       = "citations"
+)
+
+// ParquetTables lists the tables in the order they are created
+var ParquetTables = []string{TablePatents, TableClaims, TableClassifications, TableParties, TableCitations}
+
+// ParquetOptions configures a ParquetWriter.  Zero values write uncompressed files of up to DefaultRowGroupRows rows per row group.
+type ParquetOptions struct {
+	Compression  Compression
+	RowGroupRows int64 // Rows buffered per row group of each table before it is flushed.  Larger row groups compress and scan better, at the cost of memory.
+}
+
+// DefaultRowGroupRows is the row group size used when ParquetOptions.RowGroupRows is unset
+const DefaultRowGroupRows = 100_000
+
+// PatentRow is a row of the patents table, the flattened bibliographic data of a patent
+type PatentRow struct {
+	PublicationNumber string `parquet:"publication_number"`
+	DocumentType      string `parquet:"document_type,optional,dict"`
+	DocType           string `parquet:"doc_type,optional,dict"`
+	Country           string `parquet:"country,optional,dict"`
+	DocNumber         string `parquet:"doc_number,optional"`
+	Kind              string `parquet:"kind,optional,dict"`
+	PublicationDate   int32  `parquet:"publication_date,optional,date"`
+	ApplicationNumber string `parquet:"application_number,optional"`
+	ApplicationType   string `parquet:"application_type,optional,dict"`
+	ApplicationDate   int32  `parquet:"application_date,optional,date"`
+	Title             string `parquet:"title,optional"`
+	NumberOfClaims    int32  `parquet:"number_of_claims"`
+	USMainClass       string `parquet:"us_main_classification,optional"`
+	TermExtensionDays *int32 `parquet:"term_extension_days,optional"` // Null when the patent has no term of grant, unlike an extension of 0 days
+	PrimaryExaminer   string `parquet:"primary_examiner,optional"`
+	ArtUnit           string `parquet:"art_unit,optional,dict"`
+	Abstract          string `parquet:"abstract,optional"`
+	Description       string `parquet:"description,optional"`
+	Diagnostics       int32  `parquet:"diagnostics"` // Sections which could not be parsed or translated, see USPTGoConfig.Lenient
+	ZipName           string `parquet:"zip_name,optional,dict"`
+	IndexInZip        int32  `parquet:"index_in_zip"`
+	SchemaVersion     string `parquet:"schema_version,dict"`
+}
+
+// ClaimRow is a row of the claims table, one per claim of StructuredClaims
+type ClaimRow struct {
+	PublicationNumber string   `parquet:"publication_number,dict"`
+	Sequence          int32    `parquet:"sequence"` // Position among the patent's claims, from 1
+	ClaimID           string   `parquet:"claim_id,optional"`
+	Type              string   `parquet:"type,optional,dict"` // "independent" or "dependent"
+	Text              string   `parquet:"text,optional"`      // The claim text elements, joined by newlines
+	ParentIDs         []string `parquet:"parent_ids,list"`
+	Level             int32    `parquet:"level"`
+}
+
+// ClassificationRow is a row of the classifications table, one per IPC, CPC, Locarno or US classification
+type ClassificationRow struct {
+	PublicationNumber string `parquet:"publication_number,dict"`
+	Scheme            string `parquet:"scheme,dict"` // "ipcr", "cpc", "locarno" or "uspc"
+	Sequence          int32  `parquet:"sequence"`    // Position within the scheme, from 1
+	Symbol            string `parquet:"symbol"`
+	Section           string `parquet:"section,optional,dict"`
+	Class             string `parquet:"class,optional,dict"`
+	Subclass          string `parquet:"subclass,optional,dict"`
+	MainGroup         string `parquet:"main_group,optional"`
+	Subgroup          string `parquet:"subgroup,optional"`
+	Main              bool   `parquet:"main"`
+	Value             string `parquet:"value,optional,dict"`
+	VersionDate       string `parquet:"version_date,optional,dict"`
+}
+
+// PartyRow is a row of the parties table, one per applicant, inventor, agent or assignee
+type PartyRow struct {
+	PublicationNumber string `parquet:"publication_number,dict"`
+	Role              string `parquet:"role,dict"` // "applicant", "inventor", "agent" or "assignee"
+	Sequence          int32  `parquet:"sequence"`  // Position within the role, from 1
+	LastName          string `parquet:"last_name,optional"`
+	FirstName         string `parquet:"first_name,optional"`
+	Organization      string `parquet:"organization,optional"`
+	Type              string `parquet:"type,optional,dict"` // types.Party.Role, e.g. "applicant-inventor" or "attorney"
+	City              string `parquet:"city,optional"`
+	State             string `parquet:"state,optional,dict"`
+	Country           string `parquet:"country,optional,dict"`
+}
+
+// CitationRow is a row of the citations table, one per cited patent or non-patent reference
+type CitationRow struct {
+	PublicationNumber string `parquet:"publication_number,dict"`
+	Kind              string `parquet:"kind,dict"` // "patent" or "non-patent"
+	Sequence          int32  `parquet:"sequence"`  // Position among the patent's citations, from 1
+	Country           string `parquet:"country,optional,dict"`
+	DocNumber         string `parquet:"doc_number,optional"`
+	KindCode          string `parquet:"kind_code,optional,dict"`
+	Name              string `parquet:"name,optional"`
+	Date              string `parquet:"date,optional"` // YYYYMMDD as published, often without the day
+	Category          string `parquet:"category,optional,dict"`
+	Text              string `parquet:"text,optional"` // Non-patent citations only
+}
+
+// ParquetWriter writes patents to a Parquet file per table.  Trademarks are not written, and are counted by Skipped.  It is safe for concurrent use.
+type ParquetWriter struct {
+	mu              sync.Mutex
+	files           []io.WriteCloser
+	patents         *parquet.GenericWriter[PatentRow]
+	claims          *parquet.GenericWriter[ClaimRow]
+	classifications *parquet.GenericWriter[ClassificationRow]
+	parties         *parquet.GenericWriter[PartyRow]
+	citations       *parquet.GenericWriter[CitationRow]
+	skipped         int
+}
+
+// NewParquetWriter creates the file of each of the ParquetTables with create, which must return a distinct writer for each table
+func NewParquetWriter(create func(table string) (io.WriteCloser, error), opts ParquetOptions) (*ParquetWriter, error) {
+	w := &ParquetWriter{}
+
+	var codec compress.Codec = &parquet.Uncompressed
+	switch opts.Compression {
+	case CompressGzip:
+		codec = &parquet.Gzip
+	case CompressZstd:
+		codec = &parquet.Zstd
+	}
+	rowGroupRows := opts.RowGroupRows
+	if rowGroupRows <= 0 {
+		rowGroupRows = DefaultRowGroupRows
+	}
+
+	for _, table := range ParquetTables {
+		file, err := create(table)
+		if err != nil {
+			w.closeFiles()
+			return nil, err
+		}
+		w.files = append(w.files, file)
+
+		options := []parquet.WriterOption{
+			parquet.Compression(codec),
+			parquet.MaxRowsPerRowGroup(rowGroupRows),
+			parquet.KeyValueMetadata("uspt-go.table", table),
+			parquet.KeyValueMetadata("uspt-go.schema-version", ParquetSchemaVersion),
+			parquet.CreatedBy("uspt-go", ParquetSchemaVersion, ""),
+		}
+		switch table {
+		case TablePatents:
+			w.patents = parquet.NewGenericWriter[PatentRow](file, options...)
+		case TableClaims:
+			w.claims = parquet.NewGenericWriter[ClaimRow](file, options...)
+		case TableClassifications:
+			w.classifications = parquet.NewGenericWriter[ClassificationRow](file, options...)
+		case TableParties:
+			w.parties = parquet.NewGenericWriter[PartyRow](file, options...)
+		case TableCitations:
+			w.citations = parquet.NewGenericWriter[CitationRow](file, options...)
+		}
+	}
+	return w, nil
+}
+
+// CreateTableFiles returns a create function for NewParquetWriter which creates files named by pattern, a path with a single string verb for the table such as "out/ipg240102-%s.parquet"
+func CreateTableFiles(pattern string) func(table string) (io.WriteCloser, error) {
+	return func(table string) (io.WriteCloser, error) {
+		path := fmt.Sprintf(pattern, table)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		return os.Create(path)
+	}
+}
+
+// Write adds the rows of doc to each table
+func (w *ParquetWriter) Write(doc *types.USPTGoDoc) error {
+	if doc.USPTGoMetadata.DocumentType == "trademark" {
+		w.mu.Lock()
+		w.skipped++
+		w.mu.Unlock()
+		return nil
+	}
+	return w.WriteRecord(NewRecord(doc))
+}
+
+// WriteRecord adds the rows of a patent record to each table
+func (w *ParquetWriter) WriteRecord(record Record) error {
+	patent := record.Patent
+	if patent == nil {
+		return errors.New("parquet output holds patents only")
+	}
+	rows := newParquetRows(record)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.patents.Write([]PatentRow{rows.patent}); err != nil {
+		return err
+	}
+	if _, err := w.claims.Write(rows.claims); err != nil {
+		return err
+	}
+	if _, err := w.classifications.Write(rows.classifications); err != nil {
+		return err
+	}
+	if _, err := w.parties.Write(rows.parties); err != nil {
+		return err
+	}
+	if _, err := w.citations.Write(rows.citations); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Skipped is the number of trademark documents passed to Write
+func (w *ParquetWriter) Skipped() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.skipped
+}
+
+// Close flushes the last row group of each table, writes the file footers and closes the files
+func (w *ParquetWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	err := errors.Join(
+		w.patents.Close(),
+		w.claims.Close(),
+		w.classifications.Close(),
+		w.parties.Close(),
+		w.citations.Close(),
+	)
+	return errors.Join(err, w.closeFiles())
+}
+
+func (w *ParquetWriter) closeFiles() error {
+	var err error
+	for _, file := range w.files {
+		err = errors.Join(err, file.Close())
+	}
+	w.files = nil
+	return err
+}
+
+// parquetRows are the rows of a single patent across the tables
+type parquetRows struct {
+	patent          PatentRow
+	claims          []ClaimRow
+	classifications []ClassificationRow
+	parties         []PartyRow
+	citations       []CitationRow
+}
+
+func newParquetRows(record Record) parquetRows {
+	patent := record.Patent
+	key := patent.PublicationNumber
+
+	rows := parquetRows{patent: PatentRow{
+		PublicationNumber: key,
+		DocumentType:      record.DocumentType,
+		DocType:           patent.DocType,
+		Country:           patent.Publication.Country,
+		DocNumber:         patent.Publication.DocNumber,
+		Kind:              patent.Publication.Kind,
+		PublicationDate:   parquetDate(patent.PublicationDate),
+		ApplicationNumber: patent.ApplicationNumber,
+		ApplicationType:   patent.ApplicationType,
+		ApplicationDate:   parquetDate(patent.ApplicationDate),
+		Title:             patent.Title,
+		NumberOfClaims:    int32(patent.NumberOfClaims),
+		Abstract:          patent.Abstract,
+		Description:       patent.Description,
+		Diagnostics:       int32(len(record.Diagnostics)),
+		ZipName:           record.Origin.ZipName,
+		IndexInZip:        int32(record.Origin.IndexInZip),
+		SchemaVersion:     ParquetSchemaVersion,
+	}}
+	if patent.USClassification != nil {
+		rows.patent.USMainClass = patent.USClassification.Main
+	}
+	if patent.TermOfGrant != nil {
+		days := int32(patent.TermOfGrant.ExtensionDays)
+		rows.patent.TermExtensionDays = &days
+	}
+	if patent.Examiners != nil && patent.Examiners.Primary != nil {
+		primary := patent.Examiners.Primary
+		rows.patent.PrimaryExaminer = strings.TrimSpace(strings.Join([]string{primary.FirstName, primary.LastName}, " "))
+		rows.patent.ArtUnit = primary.Department
+	}
+
+	for i, claim := range patent.StructuredClaims {
+		rows.claims = append(rows.claims, ClaimRow{
+			PublicationNumber: key,
+			Sequence:          int32(i + 1),
+			ClaimID:           claim.ID,
+			Type:              claim.Type,
+			Text:              strings.Join(claim.Text, "\n"),
+			ParentIDs:         claim.ParentIDs,
+			Level:             int32(claim.Level),
+		})
+	}
+
+	addClassifications := func(scheme string, classifications []types.Classification) {
+		for i, classification := range classifications {
+			rows.classifications = append(rows.classifications, ClassificationRow{
+				PublicationNumber: key,
+				Scheme:            scheme,
+				Sequence:          int32(i + 1),
+				Symbol:            classification.Symbol,
+				Section:           classification.Section,
+				Class:             classification.Class,
+				Subclass:          classification.Subclass,
+				MainGroup:         classification.MainGroup,
+				Subgroup:          classification.Subgroup,
+				Main:              classification.Main,
+				Value:             classification.Value,
+				VersionDate:       classification.VersionDate,
+			})
+		}
+	}
+	addClassifications("ipcr", patent.Classifications.IPCR)
+	addClassifications("cpc", patent.Classifications.CPC)
+	if locarno := patent.Classifications.Locarno; locarno != nil {
+		rows.classifications = append(rows.classifications, ClassificationRow{PublicationNumber: key, Scheme: "locarno", Sequence: 1, Symbol: locarno.MainClassification, Main: true, VersionDate: locarno.Edition})
+	}
+	if uspc := patent.USClassification; uspc != nil {
+		for i, symbol := range append([]string{uspc.Main}, uspc.Further...) {
+			rows.classifications = append(rows.classifications, ClassificationRow{PublicationNumber: key, Scheme: "uspc", Sequence: int32(i + 1), Symbol: symbol, Main: i == 0})
+		}
+	}
+
+	addParties := func(role string, parties []types.Party) {
+		for i, party := range parties {
+			rows.parties = append(rows.parties, PartyRow{
+				PublicationNumber: key,
+				Role:              role,
+				Sequence:          int32(i + 1),
+				LastName:          party.LastName,
+				FirstName:         party.FirstName,
+				Organization:      party.Organization,
+				Type:              party.Role,
+				City:              party.Address.City,
+				State:             party.Address.State,
+				Country:           party.Address.Country,
+			})
+		}
+	}
+	addParties("applicant", patent.Parties.Applicants)
+	addParties("inventor", patent.Parties.Inventors)
+	addParties("agent", patent.Parties.Agents)
+	addParties("assignee", patent.Assignees)
+
+	for _, citation := range patent.Citations.Patent {
+		rows.citations = append(rows.citations, CitationRow{
+			PublicationNumber: key,
+			Kind:              "patent",
+			Sequence:          int32(len(rows.citations) + 1),
+			Country:           citation.Country,
+			DocNumber:         citation.DocNumber,
+			KindCode:          citation.Kind,
+			Name:              citation.Name,
+			Date:              citation.Date,
+			Category:          citation.Category,
+		})
+	}
+	for _, citation := range patent.Citations.NonPatent {
+		rows.citations = append(rows.citations, CitationRow{
+			PublicationNumber: key,
+			Kind:              "non-patent",
+			Sequence:          int32(len(rows.citations) + 1),
+			Category:          citation.Category,
+			Text:              citation.Text,
+		})
+	}
+	return rows
+}
+
+// parquetDate converts a YYYY-MM-DD record date to the days since the Unix epoch of a Parquet DATE.  A missing date is zero, which the optional date columns write as null; 1970-01-01 was a federal holiday, on which nothing was filed or published.
+func parquetDate(date string) int32 {
+	parsed, err := time.Parse(time.DateOnly, date)
+	if err != nil {
+		return 0
+	}
+	return int32(parsed.Unix() / 86400)
+}
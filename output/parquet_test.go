@@ -0,0 +1,179 @@
+package output
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// parquetRowTypes maps each table to its row type
+var parquetRowTypes = map[string]any{
+	TablePatents:         PatentRow{},
+	TableClaims:          ClaimRow{},
+	TableClassifications: ClassificationRow{},
+	TableParties:         PartyRow{},
+	TableCitations:       CitationRow{},
+}
+
+func TestParquetWriter(t *testing.T) {
+	pattern := filepath.Join(t.TempDir(), "out", "ipg180619-%s.parquet")
+	w, err := NewParquetWriter(CreateTableFiles(pattern), ParquetOptions{Compression: CompressZstd, RowGroupRows: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs := testDocs(t, 3)
+	docs[1].Patent.UsBibliographicData.TermOfGrant = &types.TermOfGrant{} // No extension, which is not the same as no term of grant
+	for _, doc := range docs {
+		if err := w.Write(doc); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if w.Skipped() != 0 {
+		t.Errorf("expected no skipped documents, got %d", w.Skipped())
+	}
+
+	patents := readParquet[PatentRow](t, fmt.Sprintf(pattern, TablePatents), 2)
+	if len(patents) != 3 {
+		t.Fatalf("expected 3 patents, got %d", len(patents))
+	}
+	patent := patents[0]
+	if patent.PublicationNumber != "US10000000B2" || patent.DocumentType != "grant" || patent.Title != "Widget in situ 10000000" ||
+		patent.USMainClass != "425542" || patent.NumberOfClaims != 2 || patent.SchemaVersion != ParquetSchemaVersion {
+		t.Errorf("unexpected patent row: %+v", patent)
+	}
+	if patent.PublicationDate != parquetDate("2018-06-19") || patent.ApplicationDate != parquetDate("2015-03-10") || patent.PublicationDate == 0 {
+		t.Errorf("unexpected dates: %d, %d", patent.PublicationDate, patent.ApplicationDate)
+	}
+	if patent.TermExtensionDays != nil || patent.Diagnostics != 0 {
+		t.Errorf("expected no term of grant and no diagnostics, got %v and %d", patent.TermExtensionDays, patent.Diagnostics)
+	}
+	if days := patents[1].TermExtensionDays; days == nil || *days != 0 {
+		t.Errorf("expected a term extension of 0 days, got %v", days)
+	}
+
+	claims := readParquet[ClaimRow](t, fmt.Sprintf(pattern, TableClaims), 3)
+	if len(claims) != 6 {
+		t.Fatalf("expected 6 claims, got %d", len(claims))
+	}
+	if claim := claims[1]; claim.PublicationNumber != "US10000000B2" || claim.Sequence != 2 || claim.Type != "dependent" ||
+		fmt.Sprint(claim.ParentIDs) != "[CLM-00001]" || claim.Level != 1 {
+		t.Errorf("unexpected claim row: %+v", claim)
+	}
+
+	classifications := readParquet[ClassificationRow](t, fmt.Sprintf(pattern, TableClassifications), 3)
+	if len(classifications) != 6 || classifications[0].Scheme != "cpc" || classifications[0].Symbol != "B29C 45/1775" ||
+		classifications[1].Scheme != "uspc" || classifications[1].Symbol != "425542" || !classifications[1].Main {
+		t.Errorf("unexpected classification rows: %+v", classifications)
+	}
+
+	parties := readParquet[PartyRow](t, fmt.Sprintf(pattern, TableParties), 3)
+	if len(parties) != 6 || parties[0].Role != "applicant" || parties[0].Organization != "Widget Corp." ||
+		parties[1].Role != "inventor" || parties[1].LastName != "Smith" || parties[1].Country != "JP" {
+		t.Errorf("unexpected party rows: %+v", parties)
+	}
+
+	citations := readParquet[CitationRow](t, fmt.Sprintf(pattern, TableCitations), 2)
+	if len(citations) != 3 || citations[2].PublicationNumber != "US10000002B2" || citations[2].DocNumber != "4828475" || citations[2].Category != "cited by examiner" {
+		t.Errorf("unexpected citation rows: %+v", citations)
+	}
+}
+
+// readParquet reads every row of a table file, checking its row group count and metadata
+func readParquet[T any](t *testing.T, path string, rowGroups int) []T {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := parquet.OpenFile(f, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(file.RowGroups()); n != rowGroups {
+		t.Errorf("%s: expected %d row groups, got %d", path, rowGroups, n)
+	}
+	if version, _ := file.Lookup("uspt-go.schema-version"); version != ParquetSchemaVersion {
+		t.Errorf("%s: expected schema version %s, got %q", path, ParquetSchemaVersion, version)
+	}
+
+	rows := make([]T, file.NumRows())
+	r := parquet.NewGenericReader[T](file)
+	defer r.Close()
+	if n, err := r.Read(rows); n != len(rows) {
+		t.Fatalf("%s: read %d of %d rows: %v", path, n, len(rows), err)
+	}
+	return rows
+}
+
+// TestParquetSchemaEvolution checks the tables against those of schema version 1.0: columns may be added, as optional columns, but never changed or removed
+func TestParquetSchemaEvolution(t *testing.T) {
+	current := make(map[string]string)
+	for _, table := range ParquetTables {
+		for _, column := range parquetColumns(parquetRowTypes[table]) {
+			path, rest, _ := strings.Cut(column, " ")
+			current[table+" "+path] = rest
+		}
+	}
+
+	f, err := os.Open(filepath.Join("testdata", "parquet-columns-1.0.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	released := make(map[string]bool)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		table, column, _ := strings.Cut(scanner.Text(), " ")
+		path, want, _ := strings.Cut(column, " ")
+		key := table + " " + path
+		released[key] = true
+		if got, ok := current[key]; !ok {
+			t.Errorf("%s.%s was removed", table, path)
+		} else if got != want {
+			t.Errorf("%s.%s changed from %s to %s", table, path, want, got)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	for key, column := range current {
+		if !released[key] && !strings.HasSuffix(column, " optional") {
+			t.Errorf("added column %s must be optional, is %s", key, column)
+		}
+	}
+}
+
+// parquetColumns describes the leaf columns of a row type as "path type repetition"
+func parquetColumns(row any) []string {
+	schema := parquet.SchemaOf(row)
+	var columns []string
+	for _, path := range schema.Columns() {
+		leaf, _ := schema.Lookup(path...)
+		repetition := "required"
+		switch {
+		case leaf.MaxRepetitionLevel > 0:
+			repetition = "repeated"
+		case leaf.Node.Optional():
+			repetition = "optional"
+		}
+		columns = append(columns, strings.Join(path, ".")+" "+leaf.Node.Type().String()+" "+repetition)
+	}
+	return columns
+}
@@ -0,0 +1,213 @@
+package output
+
+import (
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+// SchemaVersion is the version of the record schema, written into every Record.  Fields may be added within a major version, but are never renamed or removed.
+const SchemaVersion = "1.0"
+
+// Record is the stable serialized form of a USPTGoDoc, described by record.schema.json.  Exactly one of Patent and Trademark is set.
+type Record struct {
+	SchemaVersion string             `json:"schema-version" doc:"Version of the record schema, e.g. \"1.0\""`
+	DocumentType  string             `json:"document-type" doc:"\"grant\", \"application\" or \"trademark\""`
+	Origin        Origin             `json:"origin" doc:"Where the document was found in the bulk data"`
+	Patent        *PatentRecord      `json:"patent,omitempty"`
+	Trademark     *types.Trademark   `json:"trademark,omitempty"`
+	Diagnostics   []DiagnosticRecord `json:"diagnostics,omitempty" doc:"Sections which could not be parsed or translated, see USPTGoConfig.Lenient"`
+}
+
+// Origin locates a document within the bulk file it was split from
+type Origin struct {
+	ZipName    string `json:"zip-name" doc:"Name of the bulk file, e.g. \"ipg240102.zip\""`
+	Schema     string `json:"schema,omitempty" doc:"Registered schema of the document, e.g. \"us-patent-grant-v47\""`
+	IndexInZip int    `json:"index-in-zip" doc:"Position of the document within the bulk file"`
+	IndexName  string `json:"index-name"`
+	ByteOffset int64  `json:"byte-offset" doc:"Offset of the document within the decompressed bulk file"`
+}
+
+// PatentRecord is a patent grant or application, with its bibliographic data flattened to the top level
+type PatentRecord struct {
+	PublicationNumber string           `json:"publication-number" doc:"Canonical publication number, e.g. \"US11234567B2\""`
+	DocType           string           `json:"doc-type,omitempty" doc:"\"utility\", \"design\", \"plant\", \"reissue\", \"sir\" or \"defensive-publication\""`
+	Publication       types.DocumentID `json:"publication" doc:"The publication reference as published"`
+	PublicationDate   string           `json:"publication-date,omitempty" doc:"YYYY-MM-DD"`
+	ApplicationNumber string           `json:"application-number,omitempty" doc:"Canonical application number, e.g. \"US14643719\""`
+	Application       types.DocumentID `json:"application" doc:"The application reference as published"`
+	ApplicationType   string           `json:"application-type,omitempty" doc:"e.g. \"utility\", \"design\""`
+	ApplicationDate   string           `json:"application-date,omitempty" doc:"YYYY-MM-DD"`
+	DateProduced      string           `json:"date-produced,omitempty" doc:"YYYY-MM-DD"`
+	Lang              string           `json:"lang,omitempty"`
+	DTDVersion        string           `json:"dtd-version,omitempty"`
+	FileName          string           `json:"file-name,omitempty"`
+	Status            string           `json:"status,omitempty"`
+
+	Title            string                  `json:"title" doc:"Plain text"`
+	NumberOfClaims   int                     `json:"number-of-claims,omitempty"`
+	USClassification *USClassification       `json:"us-classification,omitempty"`
+	Classifications  types.Classifications   `json:"classifications"`
+	Parties          types.Parties           `json:"parties"`
+	Assignees        []types.Party           `json:"assignees,omitempty"`
+	Citations        types.Citations         `json:"citations"`
+	RelatedDocuments []types.RelatedDocument `json:"related-documents,omitempty"`
+	PriorityClaims   []types.PriorityClaim   `json:"priority-claims,omitempty"`
+	TermOfGrant      *types.TermOfGrant      `json:"term-of-grant,omitempty"`
+	Examiners        *types.Examiners        `json:"examiners,omitempty"`
+	PCTFiling        *types.PCTFiling        `json:"pct-filing,omitempty"`
+	PCTPublication   *types.DocumentID       `json:"pct-publication,omitempty"`
+	FieldOfSearch    *types.FieldOfSearch    `json:"field-of-search,omitempty"`
+
+	Abstract         string        `json:"abstract,omitempty" doc:"Current schema markup"`
+	Description      string        `json:"description,omitempty" doc:"HTML once translated, otherwise current schema markup"`
+	Claims           string        `json:"claims,omitempty" doc:"Current schema markup"`
+	StructuredClaims []ClaimRecord `json:"structured-claims,omitempty"`
+}
+
+// USClassification is the US national (USPC) classification
+type USClassification struct {
+	Country string   `json:"country,omitempty"`
+	Main    string   `json:"main"`
+	Further []string `json:"further,omitempty"`
+}
+
+// ClaimRecord is a single claim, linked to the claims it depends on and those depending on it
+type ClaimRecord struct {
+	ID        string   `json:"id"`
+	Type      string   `json:"type" doc:"\"independent\" or \"dependent\""`
+	Text      []string `json:"text" doc:"The claim text, then each nested claim text element"`
+	ParentIDs []string `json:"parent-ids,omitempty"`
+	ChildIDs  []string `json:"child-ids,omitempty"`
+	Level     int      `json:"level" doc:"Depth in the claim tree, 0 for an independent claim"`
+}
+
+// DiagnosticRecord is a types.Diagnostic
+type DiagnosticRecord struct {
+	Section string `json:"section"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewRecord converts doc to its Record
+func NewRecord(doc *types.USPTGoDoc) Record {
+	origin := doc.USPTGoMetadata.OriginZip
+	record := Record{
+		SchemaVersion: SchemaVersion,
+		DocumentType:  doc.USPTGoMetadata.DocumentType,
+		Origin: Origin{
+			ZipName:    origin.ZipName,
+			Schema:     origin.Schema,
+			IndexInZip: origin.IndexInZip,
+			IndexName:  origin.IndexName,
+			ByteOffset: origin.ByteOffset,
+		},
+	}
+
+	if record.DocumentType == "trademark" {
+		trademark := doc.Trademark
+		record.Trademark = &trademark
+	} else {
+		record.Patent = newPatentRecord(&doc.Patent)
+	}
+
+	for _, diagnostic := range doc.Diagnostics {
+		record.Diagnostics = append(record.Diagnostics, DiagnosticRecord{Section: string(diagnostic.Section), Code: diagnostic.Code, Message: diagnostic.Message})
+	}
+	return record
+}
+
+func newPatentRecord(patent *types.Patent) *PatentRecord {
+	biblio := patent.UsBibliographicData
+	publication := biblio.PublicationReference.DocumentID
+	application := biblio.ApplicationReference.DocumentID
+
+	record := &PatentRecord{
+		PublicationNumber: patent.Normalized.PublicationNumber,
+		DocType:           string(patent.Normalized.DocType),
+		Publication:       types.DocumentID{Country: publication.Country, DocNumber: publication.DocNumber, Kind: publication.KindCode, Date: publication.Date},
+		PublicationDate:   isoDate(patent.Normalized.PublicationDate),
+		ApplicationNumber: patent.Normalized.ApplicationNumber,
+		Application:       types.DocumentID{Country: application.Country, DocNumber: application.DocNumber, Date: application.Date},
+		ApplicationType:   biblio.ApplicationReference.ApplType,
+		ApplicationDate:   isoDate(patent.Normalized.ApplicationDate),
+		DateProduced:      isoDate(patent.Normalized.DateProduced),
+		Lang:              patent.MetaLang,
+		DTDVersion:        patent.MetaDtdVersion,
+		FileName:          patent.MetaFileName,
+		Status:            patent.MetaStatus,
+
+		Title:            plainText(biblio.InventionTitle.Content, biblio.InventionTitle.Text),
+		NumberOfClaims:   biblio.NumberOfClaims,
+		Classifications:  biblio.Classifications,
+		Parties:          biblio.Parties,
+		Assignees:        biblio.Assignees,
+		Citations:        patent.Citations,
+		RelatedDocuments: biblio.RelatedDocuments,
+		PriorityClaims:   biblio.PriorityClaims,
+		TermOfGrant:      biblio.TermOfGrant,
+		Examiners:        biblio.Examiners,
+		PCTFiling:        biblio.PCTFiling,
+		PCTPublication:   biblio.PCTPublication,
+		FieldOfSearch:    biblio.FieldOfSearch,
+
+		Abstract:    patent.Abstract.Content,
+		Description: patent.Description.Content,
+		Claims:      patent.Claims.Content,
+	}
+
+	if national := biblio.ClassificationNational; national.MainClassification != "" {
+		record.USClassification = &USClassification{
+			Country: national.Country,
+			Main:    national.MainClassification,
+			Further: national.FurtherClassifications,
+		}
+	}
+
+	for _, claim := range patent.StructuredClaims {
+		record.StructuredClaims = append(record.StructuredClaims, ClaimRecord{
+			ID:        claim.ID,
+			Type:      strings.ToLower(claim.Type),
+			Text:      claim.Text,
+			ParentIDs: claim.ClaimTree.ParentIds,
+			ChildIDs:  claim.ChildIds,
+			Level:     claim.ClaimTree.ClaimTreeLevel,
+		})
+	}
+	return record
+}
+
+// isoDate formats a normalized date, which is empty when the raw date was missing or malformed
+func isoDate(date time.Time) string {
+	if date.IsZero() {
+		return ""
+	}
+	return date.Format(time.DateOnly)
+}
+
+// plainText strips the markup of an inner XML string, such as a title with <i> or <sub> elements, falling back to the given character data when it isn't well-formed
+func plainText(innerXML, fallback string) string {
+	if !strings.Contains(innerXML, "<") && !strings.Contains(innerXML, "&") {
+		return strings.TrimSpace(innerXML)
+	}
+
+	var text strings.Builder
+	decoder := xml.NewDecoder(strings.NewReader("<t>" + innerXML + "</t>"))
+	decoder.Entity = xml.HTMLEntity
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return strings.TrimSpace(fallback)
+		}
+		if charData, ok := token.(xml.CharData); ok {
+			text.Write(charData)
+		}
+	}
+	return strings.TrimSpace(text.String())
+}
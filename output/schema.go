@@ -0,0 +1,158 @@
+package output
+
+//go:generate go run gen_schema.go
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// SchemaID is the $id of the JSON Schema describing a Record
+const SchemaID = "https://github.com/diverged/uspt-go/output/record.schema.json"
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of a Record, generated from the Go types.  It is shipped as record.schema.json, which go generate refreshes.
+func JSONSchema() ([]byte, error) {
+	g := schemaGenerator{defs: make(map[string]any), named: make(map[string]reflect.Type)}
+	schema, err := g.structSchema(reflect.TypeOf(Record{}))
+	if err != nil {
+		return nil, err
+	}
+
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = SchemaID
+	schema["title"] = "USPTGo record"
+	schema["description"] = "A USPTO patent or trademark document as written by output.JSONLWriter, record schema version " + SchemaVersion
+	schema["$defs"] = g.defs
+
+	out, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// schemaGenerator describes Go types as JSON Schema, placing each named struct type in $defs
+type schemaGenerator struct {
+	defs  map[string]any
+	named map[string]reflect.Type // The type behind each $defs entry, to catch two types of the same name
+}
+
+func (g *schemaGenerator) schemaFor(t reflect.Type) (map[string]any, error) {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.Interface:
+		return map[string]any{}, nil
+
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := g.schemaFor(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := g.schemaFor(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		if seen, ok := g.named[t.Name()]; ok {
+			if seen != t {
+				return nil, fmt.Errorf("types %s and %s share the $defs name %s", seen, t, t.Name())
+			}
+		} else {
+			g.named[t.Name()] = t
+			schema, err := g.structSchema(t)
+			if err != nil {
+				return nil, err
+			}
+			g.defs[t.Name()] = schema
+		}
+		return map[string]any{"$ref": "#/$defs/" + t.Name()}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// structSchema describes the JSON object encoding/json makes of a struct, following its json tags and the fields of embedded structs
+func (g *schemaGenerator) structSchema(t reflect.Type) (map[string]any, error) {
+	properties := make(map[string]any)
+	required := []string{}
+
+	if err := g.addFields(t, properties, &required); err != nil {
+		return nil, err
+	}
+	return map[string]any{"type": "object", "properties": properties, "required": required}, nil
+}
+
+func (g *schemaGenerator) addFields(t reflect.Type, properties map[string]any, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			if err := g.addFields(field.Type, properties, required); err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, err := g.schemaFor(field.Type)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t, field.Name, err)
+		}
+
+		omitEmpty := strings.Contains(options, "omitempty")
+		switch field.Type.Kind() {
+		case reflect.Pointer, reflect.Slice, reflect.Map:
+			// encoding/json writes null for a nil value which isn't omitted
+			if !omitEmpty && !(field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Uint8) {
+				schema = map[string]any{"anyOf": []any{schema, map[string]any{"type": "null"}}}
+			}
+		}
+		if doc := field.Tag.Get("doc"); doc != "" {
+			schema["description"] = doc
+		}
+
+		properties[name] = schema
+		if !omitEmpty {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
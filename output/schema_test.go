@@ -0,0 +1,110 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSchemaFileUpToDate(t *testing.T) {
+	schema, err := JSONSchema()
+	if err != nil {
+		t.Fatalf("JSONSchema returned an error: %v", err)
+	}
+	shipped, err := os.ReadFile("record.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(schema, shipped) {
+		t.Error("record.schema.json is out of date with the Go types, run go generate ./output")
+	}
+}
+
+func TestRecordMatchesSchema(t *testing.T) {
+	schemaJSON, err := JSONSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(schemaJSON, &schema); err != nil {
+		t.Fatal(err)
+	}
+	defs := schema["$defs"].(map[string]any)
+
+	for _, doc := range testDocs(t, 1) {
+		line, err := json.Marshal(NewRecord(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var record any
+		if err := json.Unmarshal(line, &record); err != nil {
+			t.Fatal(err)
+		}
+		checkSchema(t, "record", record, schema, defs)
+	}
+}
+
+// checkSchema checks value against the subset of JSON Schema produced by JSONSchema: types, properties, required, items and nullable anyOf
+func checkSchema(t *testing.T, path string, value any, schema map[string]any, defs map[string]any) {
+	t.Helper()
+
+	if ref, ok := schema["$ref"].(string); ok {
+		schema = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+	}
+	if anyOf, ok := schema["anyOf"].([]any); ok {
+		if value == nil {
+			return
+		}
+		schema = anyOf[0].(map[string]any)
+		if ref, ok := schema["$ref"].(string); ok {
+			schema = defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]any)
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			t.Errorf("%s: expected an object, got %T", path, value)
+			return
+		}
+		if properties, ok := schema["properties"].(map[string]any); ok {
+			for key, field := range object {
+				property, ok := properties[key].(map[string]any)
+				if !ok {
+					t.Errorf("%s: property %q is not in the schema", path, key)
+					continue
+				}
+				checkSchema(t, path+"."+key, field, property, defs)
+			}
+			for _, key := range schema["required"].([]any) {
+				if _, ok := object[key.(string)]; !ok {
+					t.Errorf("%s: required property %q is missing", path, key)
+				}
+			}
+		}
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			t.Errorf("%s: expected an array, got %T", path, value)
+			return
+		}
+		for _, item := range array {
+			checkSchema(t, path+"[]", item, schema["items"].(map[string]any), defs)
+		}
+	case "string":
+		if _, ok := value.(string); !ok {
+			t.Errorf("%s: expected a string, got %T", path, value)
+		}
+	case "integer", "number":
+		if _, ok := value.(float64); !ok {
+			t.Errorf("%s: expected a number, got %T", path, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			t.Errorf("%s: expected a boolean, got %T", path, value)
+		}
+	}
+}
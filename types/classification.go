@@ -0,0 +1,111 @@
+package types
+
+import (
+	"strings"
+)
+
+// Classifications holds the IPC, CPC and Locarno classifications of a patent document
+type Classifications struct {
+	IPCR    []Classification       `json:"ipcr,omitempty"`
+	CPC     []Classification       `json:"cpc,omitempty"` // Main classifications first, see Classification.Main
+	Locarno *LocarnoClassification `json:"locarno,omitempty"`
+}
+
+// Classification is a single IPC or CPC symbol broken into its parts, e.g. H04L 9/0861 is section "H", class "04", subclass "L", main group "9" and subgroup "0861"
+type Classification struct {
+	Symbol                string `json:"symbol"` // Canonical formatted symbol, e.g. "H04L 9/0861"
+	Section               string `json:"section"`
+	Class                 string `json:"class"`
+	Subclass              string `json:"subclass"`
+	MainGroup             string `json:"main-group"`
+	Subgroup              string `json:"subgroup"`
+	Main                  bool   `json:"main"`                      // CPC main-cpc, or IPC symbol-position "F" (first)
+	Value                 string `json:"value,omitempty"`           // "I" inventive, "A"/"N" additional
+	VersionDate           string `json:"version-date,omitempty"`    // Scheme version indicator, YYYYMMDD
+	Level                 string `json:"level,omitempty"`           // IPC classification level, e.g. "A" advanced
+	SymbolPosition        string `json:"symbol-position,omitempty"` // "F" first or "L" later
+	ActionDate            string `json:"action-date,omitempty"`     // YYYYMMDD
+	GeneratingOffice      string `json:"generating-office,omitempty"`
+	Status                string `json:"status,omitempty"`
+	DataSource            string `json:"data-source,omitempty"`
+	SchemeOriginationCode string `json:"scheme-origination-code,omitempty"` // CPC only
+}
+
+// LocarnoClassification is the international design classification of a design patent, e.g. main classification "1402" for class 14, subclass 02
+type LocarnoClassification struct {
+	Edition            string `json:"edition"`
+	MainClassification string `json:"main-classification"`
+}
+
+// FormatClassificationSymbol builds the canonical "H04L 9/0861" form of an IPC or CPC symbol.
+// Leading zeros are dropped from the main group, and the subgroup is padded to at least two digits.
+func FormatClassificationSymbol(section, class, subclass, mainGroup, subgroup string) string {
+	section = strings.TrimSpace(section)
+	class = strings.TrimSpace(class)
+	subclass = strings.TrimSpace(subclass)
+	mainGroup = strings.TrimLeft(strings.TrimSpace(mainGroup), "0")
+	subgroup = strings.TrimSpace(subgroup)
+
+	if mainGroup == "" {
+		mainGroup = "0"
+	}
+	for len(subgroup) < 2 {
+		subgroup += "0"
+	}
+	if len(class) == 1 {
+		class = "0" + class
+	}
+
+	return section + class + subclass + " " + mainGroup + "/" + subgroup
+}
+
+// ParseClassificationSymbol parses an IPC or CPC symbol written in any of the compact forms used by the bulk files,
+// e.g. "H04L 9/0861", "A01B001/00" (pap), or the fixed width "G03B 2132" (APS and v2.5, a three character main group followed by the subgroup).
+func ParseClassificationSymbol(symbol string) (Classification, bool) {
+	symbol = strings.TrimSpace(symbol)
+	if len(symbol) < 5 {
+		return Classification{}, false
+	}
+
+	head, rest := symbol[:4], symbol[4:]
+	var mainGroup, subgroup string
+	if strings.Contains(rest, "/") {
+		mainGroup, subgroup, _ = strings.Cut(rest, "/")
+	} else {
+		if len(rest) < 4 {
+			return Classification{}, false
+		}
+		mainGroup, subgroup = rest[:3], rest[3:]
+	}
+	mainGroup = strings.TrimSpace(mainGroup)
+	subgroup = strings.TrimSpace(subgroup)
+
+	if !isDigits(mainGroup) || !isDigits(subgroup) || !isDigits(head[1:3]) || head[0] < 'A' || head[0] > 'Z' {
+		return Classification{}, false
+	}
+
+	classification := Classification{
+		Section:   head[:1],
+		Class:     head[1:3],
+		Subclass:  head[3:4],
+		MainGroup: strings.TrimLeft(mainGroup, "0"),
+		Subgroup:  subgroup,
+	}
+	if classification.MainGroup == "" {
+		classification.MainGroup = "0"
+	}
+	classification.Symbol = FormatClassificationSymbol(classification.Section, classification.Class, classification.Subclass, classification.MainGroup, classification.Subgroup)
+	return classification, true
+}
+
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
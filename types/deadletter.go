@@ -0,0 +1,54 @@
+package types
+
+import "io"
+
+// DeadLetter is a sink for the documents the pipeline skips, so that they can be run again with ReprocessDeadLetters, e.g. after a parser fix.
+// Each skipped document is delivered with its raw split bytes, metadata and error.  Set Dir, Writer or both.
+type DeadLetter struct {
+	Dir    string    // Optional - directory receiving each document as <ZipName>-<hash of ZipPath>/<IndexName>, beside a <IndexName>.json DeadLetterRecord without the raw bytes
+	Writer io.Writer // Optional - receives each DeadLetterRecord as a line of JSON.  Writes are serialized across zips.
+}
+
+// Enabled reports whether a sink is set
+func (d DeadLetter) Enabled() bool {
+	return d.Dir != "" || d.Writer != nil
+}
+
+// DeadLetterRecord is a skipped document as written to a DeadLetter sink
+type DeadLetterRecord struct {
+	RawSplitDoc []byte          `json:"raw-split-doc,omitempty"` // Omitted from the .json files of a Dir, which sit beside the raw document
+	Metadata    USPTGoMetadata  `json:"metadata"`
+	Error       DeadLetterError `json:"error"`
+}
+
+// DeadLetterError is the USPTGoError which skipped a document, in a form which can be serialized
+type DeadLetterError struct {
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Whence     string `json:"whence"`
+	Type       string `json:"type"`
+	DocID      string `json:"doc-id,omitempty"`
+	ByteOffset int64  `json:"byte-offset"`
+}
+
+// NewDeadLetterRecord returns the record of a document skipped with err, which must carry the document's RawSplitDoc
+func NewDeadLetterRecord(err *USPTGoError) DeadLetterRecord {
+	// APS records, the only documents without a registered schema, are all grants
+	documentType := "grant"
+	if definition, ok := LookupSchema(err.ZipInfo.Schema); ok {
+		documentType = definition.DocumentType
+	}
+
+	return DeadLetterRecord{
+		RawSplitDoc: err.RawSplitDoc,
+		Metadata:    USPTGoMetadata{DocumentType: documentType, OriginZip: err.ZipInfo},
+		Error: DeadLetterError{
+			Code:       err.Code(),
+			Message:    err.Error(),
+			Whence:     err.Whence,
+			Type:       err.Type,
+			DocID:      err.DocID,
+			ByteOffset: err.ByteOffset,
+		},
+	}
+}
@@ -0,0 +1,33 @@
+package types
+
+// ErrorKind classifies a USPTGoError.  The kinds are sentinel errors, matched with errors.Is(err, types.ErrUnmarshal), and each has a stable Code for grouping failures in logs and alerts.
+type ErrorKind struct {
+	code    string
+	message string
+}
+
+func (k *ErrorKind) Error() string {
+	return k.message
+}
+
+// Code is the stable, machine-readable name of the kind, e.g. "unknown_schema"
+func (k *ErrorKind) Code() string {
+	return k.code
+}
+
+var (
+	ErrNotZip        = &ErrorKind{"not_zip", "input is not a zip, tar or xml bulk file"}
+	ErrOpen          = &ErrorKind{"open", "unable to open the input"}
+	ErrInspect       = &ErrorKind{"inspect", "unable to identify the contents of the input"}
+	ErrUnknownSchema = &ErrorKind{"unknown_schema", "document schema not recognized"}
+	ErrSplit         = &ErrorKind{"split", "unable to split the bulk file into documents"}
+	ErrUnmarshal     = &ErrorKind{"unmarshal", "unable to unmarshal the document"}
+	ErrClaims        = &ErrorKind{"claims", "unable to parse the structured claims"}
+	ErrTransform     = &ErrorKind{"transform", "unable to translate the description to HTML"}
+	ErrCancelled     = &ErrorKind{"cancelled", "run cancelled"}
+)
+
+// ErrorKinds lists every ErrorKind, e.g. to register their codes with an alerting system
+func ErrorKinds() []*ErrorKind {
+	return []*ErrorKind{ErrNotZip, ErrOpen, ErrInspect, ErrUnknownSchema, ErrSplit, ErrUnmarshal, ErrClaims, ErrTransform, ErrCancelled}
+}
@@ -0,0 +1,46 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestUSPTGoErrorKinds(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &USPTGoError{Err: context.Canceled, Kind: ErrCancelled, Skipped: true})
+
+	if !errors.Is(err, ErrCancelled) || !errors.Is(err, context.Canceled) || errors.Is(err, ErrUnmarshal) {
+		t.Error("errors.Is should match the Kind and the underlying error, and nothing else")
+	}
+	var kind *ErrorKind
+	if !errors.As(err, &kind) || kind.Code() != "cancelled" {
+		t.Errorf("errors.As should find the Kind, got %v", kind)
+	}
+	var usptgoErr *USPTGoError
+	if !errors.As(err, &usptgoErr) || usptgoErr.Code() != "cancelled" || usptgoErr.Error() != context.Canceled.Error() {
+		t.Errorf("errors.As should find the USPTGoError, got %v", usptgoErr)
+	}
+}
+
+func TestUSPTGoErrorWithoutErr(t *testing.T) {
+	if got := (&USPTGoError{Kind: ErrTransform}).Error(); got != ErrTransform.Error() {
+		t.Errorf("got %q", got)
+	}
+	if got := (&USPTGoError{}).Error(); got == "" {
+		t.Error("an empty USPTGoError should still describe itself")
+	}
+	if got := (&USPTGoError{}).Code(); got != "unknown" {
+		t.Errorf("got code %q", got)
+	}
+}
+
+func TestErrorKindCodesAreUnique(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, kind := range ErrorKinds() {
+		if kind.Code() == "" || seen[kind.Code()] {
+			t.Errorf("missing or duplicate code %q", kind.Code())
+		}
+		seen[kind.Code()] = true
+	}
+}
@@ -0,0 +1,23 @@
+package types
+
+// Filter selects the documents worth parsing.  Each phase is optional, and a document is kept only if every phase which is set returns true.
+// The Raw and Header phases run before a document is unmarshaled, so rejected documents skip parsing and translation entirely; Patent runs after parsing but before translation.
+// The phases run concurrently when more than one parser worker is configured.
+type Filter struct {
+	Raw    func(raw []byte, metadata USPTGoMetadata) bool // The split document as it appears in the bulk file, e.g. bytes.Contains(raw, []byte("H04L"))
+	Header func(header DocHeader) bool                    // Light header fields, read from the bibliographic section alone
+	Patent func(patent *Patent) bool                      // The parsed patent
+}
+
+// DocHeader holds the header fields of a patent document, read without unmarshaling the document in full
+type DocHeader struct {
+	DocumentType     string   // "grant" or "application"
+	Country          string   // Of the publication
+	DocNumber        string   // As published, e.g. "11234567" or "D0912345"
+	Kind             string   // Kind code, e.g. "B2"
+	Date             string   // Publication date, YYYYMMDD
+	ApplType         string   // utility, design, plant, reissue...  Empty for formats which don't record it.
+	Classifications  []string // IPC and CPC symbols, e.g. "H04L 9/0861"
+	USClassification string   // Main US classification
+	Assignees        []string // Assignee organizations, or names of individual assignees
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// InputFormat is the container of a bulk file
+type InputFormat string
+
+const (
+	InputZip InputFormat = "zip" // A zip archive, the format of most USPTO bulk products
+	InputTar InputFormat = "tar" // A tar archive, optionally gzip compressed
+	InputXML InputFormat = "xml" // An already extracted bulk XML file, optionally gzip compressed
+)
+
+// Input is a bulk file supplied as a reader rather than a path, e.g. an object storage download or a file already held in memory
+type Input struct {
+	Name     string      // Reported as OriginZip.ZipName.  Its extension selects the Format when Format is unset.
+	Format   InputFormat // Optional - overrides the extension of Name
+	ReaderAt io.ReaderAt // Random access to the file, e.g. a zip in an *os.File, *bytes.Reader or object storage range reader.  Requires Size.
+	Size     int64       // Size of the file behind ReaderAt
+	Reader   io.Reader   // The file as a stream, read once from start to end.  A zip given only as a Reader is read into memory first.
+}
+
+// InputFormatOf returns the format implied by the extension of a file name: .zip, .tar, .tar.gz, .tgz, .xml or .xml.gz.  An unsupported extension returns "".
+func InputFormatOf(name string) InputFormat {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(lower, ".tar"), strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		return InputTar
+	case strings.HasSuffix(lower, ".xml"), strings.HasSuffix(lower, ".xml.gz"):
+		return InputXML
+	case filepath.Ext(lower) == ".zip":
+		return InputZip
+	}
+	return ""
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"strings"
+	"time"
+)
+
+// DocType is the type of patent document, derived from its number prefix and kind code
+type DocType string
+
+const (
+	DocTypeUnknown              DocType = ""
+	DocTypeUtility              DocType = "utility"
+	DocTypeDesign               DocType = "design"
+	DocTypePlant                DocType = "plant"
+	DocTypeReissue              DocType = "reissue"
+	DocTypeSIR                  DocType = "sir" // Statutory invention registration
+	DocTypeDefensivePublication DocType = "defensive-publication"
+)
+
+// Normalized holds typed values derived from the raw bibliographic strings, which are left as published
+type Normalized struct {
+	PublicationNumber string    `json:"publication-number"` // e.g. "US11234567B2" or "US20240012345A1"
+	DocType           DocType   `json:"doc-type"`
+	PublicationDate   time.Time `json:"publication-date"`
+	ApplicationNumber string    `json:"application-number"` // e.g. "US14643719", keeping the series code of older numbers such as "US05491888"
+	ApplicationDate   time.Time `json:"application-date"`
+	DateProduced      time.Time `json:"date-produced"`
+}
+
+// Normalize derives p.Normalized from the bibliographic data.  It is called by every parser once the document is unmarshaled.
+func (p *Patent) Normalize() {
+	publication := p.UsBibliographicData.PublicationReference.DocumentID
+	application := p.UsBibliographicData.ApplicationReference.DocumentID
+
+	country := publication.Country
+	if country == "" {
+		country = p.MetaCountry
+	}
+	publicationDate := publication.Date
+	if publicationDate == "" {
+		publicationDate = p.MetaDatePubl
+	}
+
+	p.Normalized = Normalized{
+		PublicationNumber: CanonicalDocNumber(country, publication.DocNumber, publication.KindCode),
+		DocType:           ParseDocType(p.UsBibliographicData.ApplicationReference.ApplType, publication.DocNumber, publication.KindCode),
+		PublicationDate:   ParseDate(publicationDate),
+		ApplicationNumber: canonicalApplicationNumber(application.Country, application.DocNumber),
+		ApplicationDate:   ParseDate(application.Date),
+		DateProduced:      ParseDate(p.MetaDateProduced),
+	}
+}
+
+// ParseDate parses a YYYYMMDD date as found throughout the bulk data, returning the zero time.Time when it is empty or malformed.
+// Citation dates often omit the day (e.g. "19881200"), which is read as the first of the month.
+func ParseDate(date string) time.Time {
+	date = strings.TrimSpace(date)
+	if len(date) != 8 || !isDigits(date) {
+		return time.Time{}
+	}
+	if strings.HasSuffix(date, "0000") {
+		date = date[:4] + "0101"
+	} else if strings.HasSuffix(date, "00") {
+		date = date[:6] + "01"
+	}
+	parsed, err := time.Parse("20060102", date)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+// ParsedDate returns the document's date as a time.Time, see ParseDate
+func (d DocumentID) ParsedDate() time.Time {
+	return ParseDate(d.Date)
+}
+
+// CanonicalDocNumber builds the canonical form of a document number, with the country prefix, the zero padding removed and the kind code appended.
+// For example "US", "D0912345", "S1" gives "USD912345S1", and "US", "20240012345", "A1" gives "US20240012345A1".
+func CanonicalDocNumber(country, docNumber, kindCode string) string {
+	docNumber = strings.ToUpper(strings.TrimSpace(docNumber))
+	if docNumber == "" {
+		return ""
+	}
+	if country = strings.ToUpper(strings.TrimSpace(country)); country == "" {
+		country = "US"
+	}
+	docNumber = strings.TrimPrefix(docNumber, country)
+
+	// Split any letter prefix, such as D, PP, RE, H or T, from the digits
+	split := strings.IndexFunc(docNumber, func(r rune) bool { return r >= '0' && r <= '9' })
+	if split < 0 {
+		return country + docNumber + strings.TrimSpace(kindCode)
+	}
+	prefix, digits := docNumber[:split], docNumber[split:]
+
+	// Pre-grant publication numbers are a four digit year followed by a seven digit serial, and keep their zeros
+	if !(prefix == "" && len(digits) == 11) {
+		digits = strings.TrimLeft(digits, "0")
+	}
+
+	return country + prefix + digits + strings.TrimSpace(kindCode)
+}
+
+func canonicalApplicationNumber(country, docNumber string) string {
+	docNumber = strings.ToUpper(strings.TrimSpace(docNumber))
+	if docNumber == "" {
+		return ""
+	}
+	if country = strings.ToUpper(strings.TrimSpace(country)); country == "" {
+		country = "US"
+	}
+	return country + strings.TrimPrefix(docNumber, country)
+}
+
+// ParseDocType determines the type of a patent document.  The appl-type is used when it names a known type,
+// followed by the doc-number prefix (D, PP, RE, H, T), read after any leading US country code, and then the kind code.
+func ParseDocType(applType, docNumber, kindCode string) DocType {
+	switch DocType(strings.ToLower(strings.TrimSpace(applType))) {
+	case DocTypeUtility, DocTypeDesign, DocTypePlant, DocTypeReissue, DocTypeSIR, DocTypeDefensivePublication:
+		return DocType(strings.ToLower(strings.TrimSpace(applType)))
+	}
+
+	docNumber = strings.ToUpper(strings.TrimSpace(docNumber))
+	docNumber = strings.TrimPrefix(docNumber, "US") // A doc-number may carry its country, e.g. "USD0912345"
+	switch {
+	case strings.HasPrefix(docNumber, "RE"):
+		return DocTypeReissue
+	case strings.HasPrefix(docNumber, "PP"):
+		return DocTypePlant
+	case strings.HasPrefix(docNumber, "D"):
+		return DocTypeDesign
+	case strings.HasPrefix(docNumber, "H"):
+		return DocTypeSIR
+	case strings.HasPrefix(docNumber, "T"):
+		return DocTypeDefensivePublication
+	}
+
+	kindCode = strings.ToUpper(strings.TrimSpace(kindCode))
+	switch {
+	case kindCode == "":
+		if docNumber != "" && isDigits(docNumber) {
+			return DocTypeUtility
+		}
+		return DocTypeUnknown
+	case kindCode[0] == 'E':
+		return DocTypeReissue
+	case kindCode[0] == 'S':
+		return DocTypeDesign
+	case kindCode[0] == 'P':
+		return DocTypePlant
+	case kindCode[0] == 'H':
+		return DocTypeSIR
+	case kindCode[0] == 'A' || kindCode[0] == 'B':
+		return DocTypeUtility
+	}
+	return DocTypeUnknown
+}
@@ -0,0 +1,98 @@
+package types
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCanonicalDocNumber(t *testing.T) {
+	tests := []struct {
+		country, docNumber, kind string
+		want                     string
+	}{
+		{"US", "11234567", "B2", "US11234567B2"},
+		{"US", "06334220", "B1", "US6334220B1"},
+		{"US", "20240012345", "A1", "US20240012345A1"},
+		{"US", "D0912345", "S1", "USD912345S1"},
+		{"US", "RE049000", "E1", "USRE49000E1"},
+		{"US", "PP034567", "P3", "USPP34567P3"},
+		{"", "H0002345", "H1", "USH2345H1"},
+		{"US", "", "B2", ""},
+	}
+	for _, tt := range tests {
+		if got := CanonicalDocNumber(tt.country, tt.docNumber, tt.kind); got != tt.want {
+			t.Errorf("CanonicalDocNumber(%q, %q, %q) = %q, want %q", tt.country, tt.docNumber, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestParseDocType(t *testing.T) {
+	tests := []struct {
+		applType, docNumber, kind string
+		want                      DocType
+	}{
+		{"utility", "11234567", "B2", DocTypeUtility},
+		{"", "20240012345", "A1", DocTypeUtility},
+		{"", "D0912345", "S1", DocTypeDesign},
+		{"", "RE049000", "E1", DocTypeReissue},
+		{"", "PP034567", "P3", DocTypePlant},
+		{"", "20240000001", "P1", DocTypePlant},
+		{"", "H0002345", "", DocTypeSIR},
+		{"", "T0000001", "", DocTypeDefensivePublication},
+		{"reissue", "RE049000", "E", DocTypeReissue},
+		{"", "04000000", "", DocTypeUtility},
+		{"", "USD0912345", "", DocTypeDesign},
+		{"", "USRE49000", "", DocTypeReissue},
+		{"", "USPP34567", "", DocTypePlant},
+		{"", "USH0002345", "", DocTypeSIR},
+		{"", "US10000000", "", DocTypeUtility},
+		{"", "us11234567", "B2", DocTypeUtility},
+		{"", "", "", DocTypeUnknown},
+	}
+	for _, tt := range tests {
+		if got := ParseDocType(tt.applType, tt.docNumber, tt.kind); got != tt.want {
+			t.Errorf("ParseDocType(%q, %q, %q) = %q, want %q", tt.applType, tt.docNumber, tt.kind, got, tt.want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		date string
+		want time.Time
+	}{
+		{"20180619", time.Date(2018, 6, 19, 0, 0, 0, 0, time.UTC)},
+		{"19881200", time.Date(1988, 12, 1, 0, 0, 0, 0, time.UTC)},
+		{"19880000", time.Date(1988, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"2018061", time.Time{}},
+		{"20181340", time.Time{}},
+		{"", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := ParseDate(tt.date); !got.Equal(tt.want) {
+			t.Errorf("ParseDate(%q) = %v, want %v", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestPatentNormalize(t *testing.T) {
+	var patent Patent
+	patent.MetaDateProduced = "20180605"
+	patent.UsBibliographicData.PublicationReference.DocumentID.Country = "US"
+	patent.UsBibliographicData.PublicationReference.DocumentID.DocNumber = "10000001"
+	patent.UsBibliographicData.PublicationReference.DocumentID.KindCode = "B2"
+	patent.UsBibliographicData.PublicationReference.DocumentID.Date = "20180619"
+	patent.UsBibliographicData.ApplicationReference.ApplType = "utility"
+	patent.UsBibliographicData.ApplicationReference.DocumentID.DocNumber = "05491888"
+	patent.UsBibliographicData.ApplicationReference.DocumentID.Date = "19740124"
+
+	patent.Normalize()
+
+	normalized := patent.Normalized
+	if normalized.PublicationNumber != "US10000001B2" || normalized.DocType != DocTypeUtility || normalized.ApplicationNumber != "US05491888" {
+		t.Errorf("unexpected normalized numbers: %+v", normalized)
+	}
+	if normalized.PublicationDate.Year() != 2018 || normalized.ApplicationDate.Year() != 1974 || normalized.DateProduced.Day() != 5 {
+		t.Errorf("unexpected normalized dates: %+v", normalized)
+	}
+}
@@ -27,6 +27,8 @@ type Patent struct {
 		Content string `xml:",innerxml"`
 	} `xml:"claims"`
 	StructuredClaims []*models.Claim
+	Citations        Citations  `xml:"-" json:"citations"`
+	Normalized       Normalized `xml:"-" json:"normalized"` // Typed dates, canonical publication number and doc type, see Patent.Normalize
 }
 
 type UsBibliographicData struct {
@@ -48,14 +50,83 @@ type UsBibliographicData struct {
 		} `xml:"document-id"`
 	} `xml:"application-reference"`
 	ClassificationNational struct {
-		Country               string `xml:"country"`
-		MainClassification    string `xml:"main-classification"`
-		FurtherClassification string `xml:"further-classification"`
+		Country                string   `xml:"country"`
+		MainClassification     string   `xml:"main-classification"`
+		FurtherClassification  string   `xml:"-"` // The first of FurtherClassifications, retained for compatibility
+		FurtherClassifications []string `xml:"further-classification"`
 	} `xml:"classification-national"`
-	InventionTitle struct {
+	Classifications Classifications `xml:"-" json:"classifications"`
+	InventionTitle  struct {
 		Content string `xml:",innerxml"`
 		Text    string `xml:",chardata"`
 		ID      string `xml:"id,attr"`
 	} `xml:"invention-title"`
-	NumberOfClaims int `xml:"number-of-claims"`
+	NumberOfClaims   int               `xml:"number-of-claims"`
+	Parties          Parties           `xml:"-" json:"parties"`
+	Assignees        []Party           `xml:"-" json:"assignees,omitempty"`
+	RelatedDocuments []RelatedDocument `xml:"-" json:"related-documents,omitempty"`
+	PriorityClaims   []PriorityClaim   `xml:"-" json:"priority-claims,omitempty"`
+	TermOfGrant      *TermOfGrant      `xml:"-" json:"term-of-grant,omitempty"`
+	Examiners        *Examiners        `xml:"-" json:"examiners,omitempty"`
+	PCTFiling        *PCTFiling        `xml:"-" json:"pct-filing,omitempty"`
+	PCTPublication   *DocumentID       `xml:"-" json:"pct-publication,omitempty"`
+	FieldOfSearch    *FieldOfSearch    `xml:"-" json:"field-of-search,omitempty"`
+}
+
+// Parties groups the people and organizations named on a patent document, other than assignees
+type Parties struct {
+	Applicants []Party `json:"applicants,omitempty"`
+	Inventors  []Party `json:"inventors,omitempty"`
+	Agents     []Party `json:"agents,omitempty"`
+}
+
+// Party is a single inventor, applicant, agent or assignee.  Individuals populate LastName and FirstName, organizations populate Organization.
+type Party struct {
+	Sequence     string  `json:"sequence,omitempty"`
+	LastName     string  `json:"last-name,omitempty"`
+	FirstName    string  `json:"first-name,omitempty"`
+	Organization string  `json:"organization,omitempty"`
+	Role         string  `json:"role,omitempty"` // Applicant app-type (e.g. "applicant-inventor"), agent rep-type (e.g. "attorney") or assignee role code (e.g. "02")
+	Address      Address `json:"address"`
+	Residence    string  `json:"residence,omitempty"`   // Country of residence, applicants only
+	Nationality  string  `json:"nationality,omitempty"` // Country of nationality, applicants only
+}
+
+type Address struct {
+	Street     string `json:"street,omitempty"`
+	City       string `json:"city,omitempty"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal-code,omitempty"`
+	Country    string `json:"country,omitempty"`
+}
+
+// Citations holds the references cited on the face of a patent
+type Citations struct {
+	Patent    []PatentCitation    `json:"patent,omitempty"`
+	NonPatent []NonPatentCitation `json:"non-patent,omitempty"`
+}
+
+// Citation categories, recording which party cited the reference
+const (
+	CitedByExaminer   = "cited by examiner"
+	CitedByApplicant  = "cited by applicant"
+	CitedByOther      = "cited by other"
+	CitedByThirdParty = "cited by third party"
+)
+
+type PatentCitation struct {
+	Sequence  string `json:"sequence,omitempty"`
+	Country   string `json:"country"`
+	DocNumber string `json:"doc-number"`
+	Kind      string `json:"kind,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Date      string `json:"date,omitempty"`
+	Category  string `json:"category,omitempty"` // e.g. CitedByExaminer
+}
+
+// NonPatentCitation is a cited reference other than a patent document, such as a journal article, kept as its plain text
+type NonPatentCitation struct {
+	Sequence string `json:"sequence,omitempty"`
+	Text     string `json:"text"`
+	Category string `json:"category,omitempty"`
 }
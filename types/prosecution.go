@@ -0,0 +1,38 @@
+package types
+
+// TermOfGrant records the adjustments to a patent's term from us-term-of-grant
+type TermOfGrant struct {
+	ExtensionDays int    `json:"extension-days,omitempty"`  // Patent term adjustment (us-term-extension), in days
+	LengthOfGrant int    `json:"length-of-grant,omitempty"` // In years, given on design patents
+	Disclaimer    string `json:"disclaimer,omitempty"`      // Terminal disclaimer text
+}
+
+// Examiners names the primary and assistant examiners of a grant
+type Examiners struct {
+	Primary   *Examiner `json:"primary,omitempty"`
+	Assistant *Examiner `json:"assistant,omitempty"`
+}
+
+type Examiner struct {
+	LastName   string `json:"last-name"`
+	FirstName  string `json:"first-name,omitempty"`
+	Department string `json:"department,omitempty"` // Art unit, e.g. "3742"
+}
+
+// PCTFiling is the international (PCT) or regional application a US national stage application entered from
+type PCTFiling struct {
+	DocumentID DocumentID `json:"document-id"`
+	Date371    string     `json:"date-371,omitempty"` // Date the 35 U.S.C. 371 requirements were met, YYYYMMDD
+}
+
+// FieldOfSearch lists the classifications searched by the examiner
+type FieldOfSearch struct {
+	National []SearchClassification `json:"national,omitempty"`
+	CPC      []string               `json:"cpc,omitempty"` // classification-cpc-text, e.g. "B29C 45/17"
+}
+
+type SearchClassification struct {
+	Country            string `json:"country"`
+	MainClassification string `json:"main-classification"`
+	AdditionalInfo     string `json:"additional-info,omitempty"` // e.g. "unstructured"
+}
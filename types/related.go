@@ -0,0 +1,47 @@
+package types
+
+// RelationType names the kind of a related document, using the element names of us-related-documents
+type RelationType string
+
+const (
+	RelationContinuation           RelationType = "continuation"
+	RelationContinuationInPart     RelationType = "continuation-in-part"
+	RelationDivision               RelationType = "division"
+	RelationReissue                RelationType = "reissue"
+	RelationSubstitution           RelationType = "substitution"
+	RelationReexamination          RelationType = "reexamination"
+	RelationContinuingReexam       RelationType = "continuing-reexamination"
+	RelationReexamReissueMerger    RelationType = "us-reexamination-reissue-merger"
+	RelationCorrection             RelationType = "correction"
+	RelationProvisionalApplication RelationType = "us-provisional-application"
+	RelationRelatedPublication     RelationType = "related-publication"
+)
+
+// RelatedDocument is a single family relation of a patent document.
+// Continuations, divisionals, reissues and the like link a Parent to a Child application.
+// Provisional applications and related publications have no child, and record the referenced document as Parent.
+type RelatedDocument struct {
+	Type         RelationType `json:"type"`
+	Parent       DocumentID   `json:"parent"`
+	ParentStatus string       `json:"parent-status,omitempty"` // e.g. "PENDING", "ABANDONED", "GRANTED"
+	ParentGrant  *DocumentID  `json:"parent-grant,omitempty"`  // The patent granted on the parent application
+	ParentPCT    *DocumentID  `json:"parent-pct,omitempty"`    // The PCT application the parent entered from
+	Child        *DocumentID  `json:"child,omitempty"`
+}
+
+// PriorityClaim is a claim to the filing date of an earlier, usually foreign, application
+type PriorityClaim struct {
+	Sequence  string `json:"sequence,omitempty"`
+	Kind      string `json:"kind,omitempty"` // "national", "regional" or "international"
+	Country   string `json:"country"`
+	DocNumber string `json:"doc-number"`
+	Date      string `json:"date"` // Filing date, YYYYMMDD
+}
+
+// DocumentID identifies a patent document or application, with its filing or publication date
+type DocumentID struct {
+	Country   string `json:"country,omitempty"`
+	DocNumber string `json:"doc-number"`
+	Kind      string `json:"kind,omitempty"`
+	Date      string `json:"date,omitempty"`
+}
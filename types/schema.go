@@ -0,0 +1,126 @@
+package types
+
+import (
+	"bytes"
+	"path"
+	"regexp"
+	"sync"
+)
+
+// SchemaFormat names the family of parsers which handles a schema
+type SchemaFormat string
+
+const (
+	FormatUSPatent  SchemaFormat = "us-patent" // us-patent-grant and us-patent-application, v4.0 onward (2005 - present)
+	FormatST32      SchemaFormat = "st32"      // <PATDOC> v2.5 grants (2001 - 2004)
+	FormatPAP       SchemaFormat = "pap"       // <patent-application-publication> pre-grant publications (2001 - 2004)
+	FormatTrademark SchemaFormat = "trademark" // trademark-applications-daily
+)
+
+// SchemaDefinition declares a schema which USPTGo recognizes.  Documents are matched on the DTD named by their DOCTYPE, or failing that on their root element.
+type SchemaDefinition struct {
+	DTD          string       // DTD file named by the DOCTYPE system identifier, e.g. "us-patent-grant-v47-2022-02-17.dtd"
+	Root         string       // Optional - root element identifying documents which carry no DOCTYPE
+	DocumentType string       // "grant", "application" or "trademark"
+	Version      int8         // Reported as OriginZip.SchemaVersion
+	Format       SchemaFormat // Parser family of the schema
+}
+
+// Name is the DTD of the schema, or its root element when it has no DTD.  It is reported as OriginZip.Schema.
+func (d SchemaDefinition) Name() string {
+	if d.DTD != "" {
+		return d.DTD
+	}
+	return d.Root
+}
+
+var schemaRegistry = struct {
+	sync.RWMutex
+	definitions []SchemaDefinition
+}{
+	definitions: []SchemaDefinition{
+		{DTD: "us-patent-grant-v47-2022-02-17.dtd", DocumentType: "grant", Version: 47, Format: FormatUSPatent},
+		{DTD: "us-patent-grant-v46-2021-08-30.dtd", DocumentType: "grant", Version: 46, Format: FormatUSPatent},
+		{DTD: "us-patent-grant-v45-2014-04-03.dtd", DocumentType: "grant", Version: 45, Format: FormatUSPatent},
+		{DTD: "us-patent-grant-v44-2013-05-16.dtd", DocumentType: "grant", Version: 44, Format: FormatUSPatent},
+		{DTD: "us-patent-grant-v43-2012-12-04.dtd", DocumentType: "grant", Version: 43, Format: FormatUSPatent},
+		{DTD: "us-patent-grant-v42-2006-08-23.dtd", DocumentType: "grant", Version: 42, Format: FormatUSPatent},
+		{DTD: "us-patent-grant-v41-2005-08-25.dtd", DocumentType: "grant", Version: 41, Format: FormatUSPatent},
+		{DTD: "us-patent-grant-v40-2004-12-02.dtd", DocumentType: "grant", Version: 40, Format: FormatUSPatent},
+		{DTD: "ST32-US-Grant-025xml.dtd", DocumentType: "grant", Version: 25, Format: FormatST32},
+		{DTD: "us-patent-application-v46-2022-02-17.dtd", DocumentType: "application", Version: 46, Format: FormatUSPatent},
+		{DTD: "us-patent-application-v45-2021-08-30.dtd", DocumentType: "application", Version: 45, Format: FormatUSPatent},
+		{DTD: "us-patent-application-v44-2014-04-03.dtd", DocumentType: "application", Version: 44, Format: FormatUSPatent},
+		{DTD: "us-patent-application-v43-2012-12-04.dtd", DocumentType: "application", Version: 43, Format: FormatUSPatent},
+		{DTD: "us-patent-application-v42-2006-08-23.dtd", DocumentType: "application", Version: 42, Format: FormatUSPatent},
+		{DTD: "us-patent-application-v41-2005-08-25.dtd", DocumentType: "application", Version: 41, Format: FormatUSPatent},
+		{DTD: "us-patent-application-v40-2004-12-02.dtd", DocumentType: "application", Version: 40, Format: FormatUSPatent},
+		{DTD: "pap-v16-2002-01-01.dtd", DocumentType: "application", Version: 16, Format: FormatPAP},
+		{DTD: "pap-v15-2001-01-31.dtd", DocumentType: "application", Version: 15, Format: FormatPAP},
+		{Root: "trademark-applications-daily", DocumentType: "trademark", Version: 2, Format: FormatTrademark},
+	},
+}
+
+// RegisterSchema adds a schema to the registry consulted for every document, e.g. a new DTD revision handled by an existing parser family.
+// A definition with the same Name as a registered one replaces it.  Register schemas before starting a run.
+func RegisterSchema(definition SchemaDefinition) {
+	schemaRegistry.Lock()
+	defer schemaRegistry.Unlock()
+
+	for i, registered := range schemaRegistry.definitions {
+		if registered.Name() == definition.Name() {
+			schemaRegistry.definitions[i] = definition
+			return
+		}
+	}
+	schemaRegistry.definitions = append(schemaRegistry.definitions, definition)
+}
+
+// RegisteredSchemas returns a copy of the registry
+func RegisteredSchemas() []SchemaDefinition {
+	schemaRegistry.RLock()
+	defer schemaRegistry.RUnlock()
+
+	return append([]SchemaDefinition(nil), schemaRegistry.definitions...)
+}
+
+// LookupSchema returns the registered schema of the given Name
+func LookupSchema(name string) (SchemaDefinition, bool) {
+	schemaRegistry.RLock()
+	defer schemaRegistry.RUnlock()
+
+	for _, definition := range schemaRegistry.definitions {
+		if definition.Name() == name {
+			return definition, true
+		}
+	}
+	return SchemaDefinition{}, false
+}
+
+// doctypePattern captures the root element and system identifier of a DOCTYPE, declared either SYSTEM "id" or PUBLIC "public-id" "id"
+var doctypePattern = regexp.MustCompile(`<!DOCTYPE\s+([^\s\[>]+)\s+(?:SYSTEM|PUBLIC\s+"[^"]*")\s+"([^"]+)"`)
+
+// DetectSchema identifies the schema of a document from its head, which need only span the prolog and root start tag.
+// It returns the matched definition and the DTD as named by the document, which is empty for documents matched on their root element.
+func DetectSchema(head []byte) (definition SchemaDefinition, dtd string, ok bool) {
+	definitions := RegisteredSchemas()
+
+	if match := doctypePattern.FindSubmatch(head); match != nil {
+		dtd = string(match[2])
+		name := path.Base(dtd)
+		for _, definition := range definitions {
+			if definition.DTD != "" && definition.DTD == name {
+				return definition, dtd, true
+			}
+		}
+		return SchemaDefinition{}, dtd, false
+	}
+
+	// Without a DOCTYPE, fall back on the root element
+	for _, definition := range definitions {
+		if definition.Root != "" && bytes.Contains(head, []byte("<"+definition.Root)) {
+			return definition, "", true
+		}
+	}
+	return SchemaDefinition{}, "", false
+}
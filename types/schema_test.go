@@ -0,0 +1,89 @@
+package types
+
+import "testing"
+
+func TestDetectSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    string
+		schema  string
+		dtd     string
+		version int8
+		ok      bool
+	}{
+		{
+			name:    "SYSTEM identifier",
+			head:    `<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<!DOCTYPE us-patent-application SYSTEM "us-patent-application-v43-2012-12-04.dtd" [ ]>`,
+			schema:  "us-patent-application-v43-2012-12-04.dtd",
+			dtd:     "us-patent-application-v43-2012-12-04.dtd",
+			version: 43,
+			ok:      true,
+		},
+		{
+			name:    "PUBLIC identifier",
+			head:    `<?xml version="1.0"?><!DOCTYPE PATDOC PUBLIC "-//USPTO//DTD ST.32 US PATENT GRANT V2.5 2000-09-20//EN" "ST32-US-Grant-025xml.dtd" [`,
+			schema:  "ST32-US-Grant-025xml.dtd",
+			dtd:     "ST32-US-Grant-025xml.dtd",
+			version: 25,
+			ok:      true,
+		},
+		{
+			name:    "DTD with a path",
+			head:    `<!DOCTYPE us-patent-grant SYSTEM "dtds/us-patent-grant-v44-2013-05-16.dtd">`,
+			schema:  "us-patent-grant-v44-2013-05-16.dtd",
+			dtd:     "dtds/us-patent-grant-v44-2013-05-16.dtd",
+			version: 44,
+			ok:      true,
+		},
+		{
+			name:    "Root element without a DOCTYPE",
+			head:    `<?xml version="1.0" encoding="UTF-8"?><trademark-applications-daily>`,
+			schema:  "trademark-applications-daily",
+			version: 2,
+			ok:      true,
+		},
+		{
+			name: "Unknown DTD",
+			head: `<!DOCTYPE us-patent-grant SYSTEM "us-patent-grant-v99-2099-01-01.dtd" [ ]>`,
+			dtd:  "us-patent-grant-v99-2099-01-01.dtd",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			definition, dtd, ok := DetectSchema([]byte(tt.head))
+			if ok != tt.ok || definition.Name() != tt.schema || dtd != tt.dtd || definition.Version != tt.version {
+				t.Errorf("DetectSchema() = %q, %q, version %d, %v, want %q, %q, version %d, %v", definition.Name(), dtd, definition.Version, ok, tt.schema, tt.dtd, tt.version, tt.ok)
+			}
+		})
+	}
+}
+
+func TestRegisterSchema(t *testing.T) {
+	registered := RegisteredSchemas()
+	t.Cleanup(func() {
+		schemaRegistry.Lock()
+		schemaRegistry.definitions = registered
+		schemaRegistry.Unlock()
+	})
+
+	head := []byte(`<!DOCTYPE us-patent-grant SYSTEM "us-patent-grant-v48-2025-01-01.dtd" [ ]>`)
+	if _, _, ok := DetectSchema(head); ok {
+		t.Fatal("expected v48 to be unknown before it is registered")
+	}
+
+	RegisterSchema(SchemaDefinition{DTD: "us-patent-grant-v48-2025-01-01.dtd", DocumentType: "grant", Version: 48, Format: FormatUSPatent})
+	definition, _, ok := DetectSchema(head)
+	if !ok || definition.Version != 48 || definition.Format != FormatUSPatent {
+		t.Errorf("expected the registered v48 schema, got %+v, %v", definition, ok)
+	}
+
+	// Registering the same DTD again replaces the definition
+	RegisterSchema(SchemaDefinition{DTD: "us-patent-grant-v48-2025-01-01.dtd", DocumentType: "grant", Version: 49, Format: FormatUSPatent})
+	if definition, _ := LookupSchema("us-patent-grant-v48-2025-01-01.dtd"); definition.Version != 49 {
+		t.Errorf("expected the replaced definition, got %+v", definition)
+	}
+	if len(RegisteredSchemas()) != len(registered)+1 {
+		t.Errorf("expected one schema to be added, got %d", len(RegisteredSchemas())-len(registered))
+	}
+}
@@ -0,0 +1,48 @@
+package types
+
+import "time"
+
+// StageCounts counts the documents passing each stage of the pipeline
+type StageCounts struct {
+	Split        int // Documents split from the bulk files
+	Prefiltered  int // Rejected by Filter.Raw or Filter.Header
+	Parsed       int // Unmarshaled successfully
+	Postfiltered int // Rejected by Filter.Patent
+	Translated   int // Translated to HTML.  Zero when the Projection excludes the description.
+	Documents    int // Sent to docChan
+	Partial      int // Sent to docChan with Diagnostics
+	Errors       int // Sent to errChan
+}
+
+// ZipStats reports the progress of a single input
+type ZipStats struct {
+	StageCounts
+	ZipPath    string
+	Index      int            // Position of the zip among the resolved inputs
+	Started    bool           // The zip's pipeline has begun
+	Finished   bool           // Every document and error of the zip has been sent
+	Skipped    map[string]int // Skipped documents and files, counted by the Whence of their USPTGoError
+	BytesRead  int64          // Of the entries of a zip, uncompressed, or of a tar or xml file as stored
+	BytesTotal int64          // Zero when unknown, as for a stream given without its Size
+
+	// Time from the start of the zip until each stage had processed its last document.  Zero until the stage finishes.
+	SplitTime     time.Duration
+	ParseTime     time.Duration
+	TranslateTime time.Duration
+
+	Elapsed            time.Duration
+	DocumentsPerSecond float64
+}
+
+// Stats reports the progress of a run, totalled over its inputs
+type Stats struct {
+	StageCounts
+	Zips               []ZipStats     // One per resolved input, in order
+	Skipped            map[string]int // Skipped documents and files, counted by the Whence of their USPTGoError
+	BytesRead          int64
+	BytesTotal         int64 // Of the inputs opened so far
+	Elapsed            time.Duration
+	DocumentsPerSecond float64
+	BytesPerSecond     float64
+	Done               bool // The run has finished, and the counts are final
+}
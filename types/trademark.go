@@ -0,0 +1,85 @@
+package types
+
+// Trademark is a single <case-file> from the USPTO trademark daily application/registration XML (trademark-applications-daily)
+type Trademark struct {
+	RawSplitDoc        []byte                      `xml:"-" json:"-"` // Entire <case-file> XML as represented in the originating bulk file
+	SerialNumber       string                      `xml:"serial-number" json:"serial-number"`
+	RegistrationNumber string                      `xml:"registration-number" json:"registration-number"`
+	TransactionDate    string                      `xml:"transaction-date" json:"transaction-date"`
+	Header             TrademarkHeader             `xml:"case-file-header" json:"header"`
+	Statements         []TrademarkStatement        `xml:"case-file-statements>case-file-statement" json:"statements,omitempty"`
+	GoodsAndServices   []TrademarkGoodsAndServices `xml:"-" json:"goods-and-services,omitempty"` // Derived from the "GS" statements
+	Events             []TrademarkEvent            `xml:"case-file-event-statements>case-file-event-statement" json:"events,omitempty"`
+	Classifications    []TrademarkClassification   `xml:"classifications>classification" json:"classifications,omitempty"`
+	Owners             []TrademarkOwner            `xml:"case-file-owners>case-file-owner" json:"owners,omitempty"`
+	Correspondent      TrademarkCorrespondent      `xml:"correspondent" json:"correspondent"`
+}
+
+type TrademarkHeader struct {
+	FilingDate                 string `xml:"filing-date" json:"filing-date"`
+	RegistrationDate           string `xml:"registration-date" json:"registration-date,omitempty"`
+	StatusCode                 string `xml:"status-code" json:"status-code"`
+	StatusDate                 string `xml:"status-date" json:"status-date"`
+	MarkIdentification         string `xml:"mark-identification" json:"mark-identification"`
+	MarkDrawingCode            string `xml:"mark-drawing-code" json:"mark-drawing-code"`
+	StandardCharactersClaimed  string `xml:"standard-characters-claimed-in" json:"standard-characters-claimed,omitempty"`
+	PublishedForOppositionDate string `xml:"published-for-opposition-date" json:"published-for-opposition-date,omitempty"`
+	AbandonmentDate            string `xml:"abandonment-date" json:"abandonment-date,omitempty"`
+	CancellationDate           string `xml:"cancellation-date" json:"cancellation-date,omitempty"`
+	AttorneyName               string `xml:"attorney-name" json:"attorney-name,omitempty"`
+	EmployeeName               string `xml:"employee-name" json:"employee-name,omitempty"`
+	LawOfficeCode              string `xml:"law-office-assigned-location-code" json:"law-office-code,omitempty"`
+}
+
+// TrademarkStatement is a free text statement, such as goods and services ("GS" type codes), disclaimers or translations
+type TrademarkStatement struct {
+	TypeCode string `xml:"type-code" json:"type-code"`
+	Text     string `xml:"text" json:"text"`
+}
+
+type TrademarkGoodsAndServices struct {
+	Class string `json:"class"` // International class, e.g. "009"
+	Text  string `json:"text"`
+}
+
+// TrademarkEvent is a single prosecution event, e.g. code "NWAP" for a new application
+type TrademarkEvent struct {
+	Code        string `xml:"code" json:"code"`
+	Type        string `xml:"type" json:"type"`
+	Description string `xml:"description-text" json:"description"`
+	Date        string `xml:"date" json:"date"`
+	Number      int    `xml:"number" json:"number"`
+}
+
+type TrademarkClassification struct {
+	InternationalCodes     []string `xml:"international-code" json:"international-codes,omitempty"`
+	USCodes                []string `xml:"us-code" json:"us-codes,omitempty"`
+	PrimaryCode            string   `xml:"primary-code" json:"primary-code"`
+	StatusCode             string   `xml:"status-code" json:"status-code"`
+	StatusDate             string   `xml:"status-date" json:"status-date"`
+	FirstUseAnywhereDate   string   `xml:"first-use-anywhere-date" json:"first-use-anywhere-date,omitempty"`
+	FirstUseInCommerceDate string   `xml:"first-use-in-commerce-date" json:"first-use-in-commerce-date,omitempty"`
+}
+
+type TrademarkOwner struct {
+	EntryNumber         string `xml:"entry-number" json:"entry-number"`
+	PartyType           string `xml:"party-type" json:"party-type"`
+	PartyName           string `xml:"party-name" json:"party-name"`
+	LegalEntityTypeCode string `xml:"legal-entity-type-code" json:"legal-entity-type-code"`
+	NationalityCountry  string `xml:"nationality>country" json:"nationality-country,omitempty"`
+	NationalityState    string `xml:"nationality>state" json:"nationality-state,omitempty"`
+	Address1            string `xml:"address-1" json:"address-1,omitempty"`
+	Address2            string `xml:"address-2" json:"address-2,omitempty"`
+	City                string `xml:"city" json:"city,omitempty"`
+	State               string `xml:"state" json:"state,omitempty"`
+	Country             string `xml:"country" json:"country,omitempty"`
+	Postcode            string `xml:"postcode" json:"postcode,omitempty"`
+}
+
+type TrademarkCorrespondent struct {
+	Address1 string `xml:"address-1" json:"address-1,omitempty"`
+	Address2 string `xml:"address-2" json:"address-2,omitempty"`
+	Address3 string `xml:"address-3" json:"address-3,omitempty"`
+	Address4 string `xml:"address-4" json:"address-4,omitempty"`
+	Address5 string `xml:"address-5" json:"address-5,omitempty"`
+}
@@ -1,9 +1,77 @@
 package types
 
+import "time"
+
 type USPTGoConfig struct {
-	InputPath         string // Path to the input zip file
-	ReturnRawSplitDoc bool   // Optional - return the raw split XML document in addition to the parsed document.  True by default.  False will save memory.
-	Logger            Logger // Optional - provide a logger interface
+	InputPath         string         // Path to an input zip, tar, tar.gz or extracted xml bulk file, a directory of them, or a glob such as "/data/ipg*.zip"
+	InputPaths        []string       // Optional - further input paths, each accepted in the same forms as InputPath
+	Inputs            []Input        // Optional - bulk files supplied as readers, processed after the paths
+	ReturnRawSplitDoc bool           // Optional - also return the raw split document in USPTGoDoc.RawSplitDoc.  False by default, which saves memory.
+	Projection        Projection     // Optional - the sections of each patent to parse.  Full text by default.
+	Filter            Filter         // Optional - predicates selecting the documents to parse
+	DeadLetter        DeadLetter     // Optional - sink receiving the raw bytes of each skipped document
+	Lenient           bool           // Optional - send a patent with the sections which parsed, recording the others in USPTGoDoc.Diagnostics, rather than skipping it
+	Logger            Logger         // Optional - provide a logger interface
+	Tuning            Tuning         // Optional - worker pool and channel buffer sizes.  Zero values use the defaults.
+	OnZipEvent        func(ZipEvent) // Optional - called as each zip starts and finishes.  Called from several goroutines when MaxConcurrentZips > 1.
+	Progress          func(Stats)    // Optional - called every ProgressInterval while the run proceeds, and once more with the final Stats before the channels close
+	ProgressInterval  time.Duration  // Optional - default 1 second
+}
+
+// Tuning controls the concurrency of the pipeline.  The defaults run one parser and one translator per zip, which keeps documents in IndexInZip order.
+type Tuning struct {
+	ParserWorkers     int  // Goroutines unmarshaling split documents.  Default 1.
+	TranslatorWorkers int  // Goroutines translating XML text to HTML.  Default 1.
+	BufferSize        int  // Capacity of the channels between pipeline stages.  Default 100.
+	OutputBufferSize  int  // Capacity of the returned docChan and errChan.  Default 1000.
+	PreserveOrder     bool // Emit documents in IndexInZip order even when more than one worker runs a stage
+	MaxConcurrentZips int  // Zips processed at once, each with its own pipeline.  Default 1.
+}
+
+// Projection selects the sections of each patent which are parsed.  Unrequested sections are cut from the document before unmarshaling and are left empty.
+type Projection int
+
+const (
+	ProjectFullText      Projection = iota // Every section, with the description translated to HTML
+	ProjectBibliographic                   // Bibliographic data and citations only
+	ProjectClaims                          // Bibliographic data and the claims, including StructuredClaims
+	ProjectAbstract                        // Bibliographic data, the claims and the abstract
+)
+
+// IncludesClaims reports whether the claims are parsed
+func (p Projection) IncludesClaims() bool {
+	return p != ProjectBibliographic
+}
+
+// IncludesAbstract reports whether the abstract is parsed
+func (p Projection) IncludesAbstract() bool {
+	return p == ProjectFullText || p == ProjectAbstract
+}
+
+// IncludesDescription reports whether the description is parsed and translated
+func (p Projection) IncludesDescription() bool {
+	return p == ProjectFullText
+}
+
+// ZipEventKind distinguishes the events reported to USPTGoConfig.OnZipEvent
+type ZipEventKind string
+
+const (
+	ZipStarted  ZipEventKind = "started"
+	ZipFinished ZipEventKind = "finished"
+)
+
+// ZipEvent reports the progress of a single input zip.  Documents and Errors count what the zip sent to docChan and errChan, and like the filter counts are only set on ZipFinished.
+type ZipEvent struct {
+	Kind         ZipEventKind
+	ZipPath      string // Path of the input, or the Name of a reader input
+	Index        int    // Position of the zip among the resolved inputs
+	Total        int    // Number of resolved inputs
+	Documents    int
+	Errors       int
+	Prefiltered  int           // Documents rejected by Filter.Raw or Filter.Header
+	Postfiltered int           // Documents rejected by Filter.Patent
+	Elapsed      time.Duration // Time spent on the zip, set on ZipFinished
 }
 
 // Logger defines a simple interface for logging within the parser.
@@ -17,31 +85,77 @@ type Logger interface {
 /*
 USPTGoError enables centralized reporting of problems encounterd with specific files, especially skipped files.
 Example Report: "%Type file skipped [%Name] due to error encountered while %Whence.\n Error: %v\n\n"
+Kind classifies the error for errors.Is, and Code names it for grouping.
 */
 type USPTGoError struct {
-	Err     error  // The error encountered
-	Skipped bool   // Whether the file was skipped
-	Name    string // Zip name, Index within Zip, Document ID, etc.
-	Whence  string // verb phrase, e.g. "opening the file", "reading the file", etc.
-	Type    string // Zip, Part of Zip, Patent Doc, etc.
-	ZipInfo OriginZip
+	Err        error      // The error encountered
+	Kind       *ErrorKind // Class of the error, e.g. ErrUnmarshal
+	Skipped    bool       // Whether the file was skipped
+	Name       string     // Zip name, Index within Zip, Document ID, etc.
+	Whence     string     // verb phrase, e.g. "opening the file", "reading the file", etc.
+	Type       string     // Zip, Part of Zip, Patent Doc, etc.
+	DocID      string     // Publication number of the document, e.g. "US10000001B2", when it could be read
+	ByteOffset int64      // Offset of the document within its bulk file, or for ErrSplit of the last document begun before the failure
+	ZipInfo    OriginZip  // The input, and for document errors the document's place within it
+
+	// The raw split document of a skipped document, delivered to the DeadLetter sink.  Dropped before the error reaches errChan unless ReturnRawSplitDoc is set.
+	RawSplitDoc []byte
 }
 
 func (e *USPTGoError) Error() string {
+	if e.Err == nil && e.Kind != nil {
+		return e.Kind.Error()
+	}
+	if e.Err == nil {
+		return "unknown error"
+	}
 	return e.Err.Error()
 }
 
+// Code is the stable code of the error's Kind, or "unknown" for an error without one
+func (e *USPTGoError) Code() string {
+	if e.Kind == nil {
+		return "unknown"
+	}
+	return e.Kind.Code()
+}
+
+// Unwrap exposes the underlying error and the Kind to errors.Is and errors.As, e.g. errors.Is(err, context.Canceled) or errors.Is(err, types.ErrUnknownSchema)
+func (e *USPTGoError) Unwrap() []error {
+	var errs []error
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	return errs
+}
+
 // USPTGoDoc is the object returned via docChan
 type USPTGoDoc struct {
 	USPTGoMetadata USPTGoMetadata
 	RawSplitDoc    []byte // Entire XML document as represented in the originating bulk file
 	Patent         Patent
 	Trademark      Trademark
+	Diagnostics    []Diagnostic // Sections which could not be parsed or translated, and were left empty or as XML
 }
 
-// Trademark
-type Trademark struct {
-	RawSplitDoc []byte // Entire XML document as represented in the originating bulk file
+// Section names a part of a patent which is parsed on its own
+type Section string
+
+const (
+	SectionBibliographic Section = "bibliographic"
+	SectionAbstract      Section = "abstract"
+	SectionDescription   Section = "description"
+	SectionClaims        Section = "claims"
+)
+
+// Diagnostic records a problem with a single section of a document which was sent regardless
+type Diagnostic struct {
+	Section Section
+	Code    string // Code of the ErrorKind of the problem, e.g. "claims"
+	Message string
 }
 
 // USPT-Go generated metadata
@@ -55,8 +169,10 @@ type OriginZip struct {
 	ZipPath       string
 	ZipName       string
 	ZipEntryExt   string
-	Schema        string
+	Schema        string // Name of the registered schema, see RegisterSchema
 	SchemaVersion int8
+	DTD           string // DTD named by the document's own DOCTYPE, as written in the document
 	IndexInZip    int
 	IndexName     string
+	ByteOffset    int64 // Offset of the document within its bulk file, after decompression
 }
@@ -1,25 +1,153 @@
 package usptgo
 
 import (
+	"context"
+	"errors"
+	"iter"
+
 	"github.com/diverged/uspt-go/internal"
+	"github.com/diverged/uspt-go/internal/stats"
 	"github.com/diverged/uspt-go/types"
 )
 
 // USPTGo accepts a USPTGoConfig and returns one USPTGoDoc channel and one USPTGoError channel
 func USPTGo(cfg *types.USPTGoConfig) (<-chan *types.USPTGoDoc, <-chan error, error) {
+	return USPTGoWithContext(context.Background(), cfg)
+}
+
+// USPTGoWithContext is USPTGo bound to ctx.  Cancelling ctx stops every pipeline stage, after which both channels are closed and ctx.Err() is reported on the error channel.
+// Cancel ctx before abandoning a partially read docChan, otherwise the pipeline goroutines remain blocked.
+func USPTGoWithContext(ctx context.Context, cfg *types.USPTGoConfig) (<-chan *types.USPTGoDoc, <-chan error, error) {
+
+	run, err := Start(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return run.Docs, run.Errs, nil
+
+}
+
+// Run is a pipeline started by Start.  Its channels behave as those returned by USPTGoWithContext, and Stats may be polled while they are read.
+type Run struct {
+	Docs <-chan *types.USPTGoDoc
+	Errs <-chan error
+
+	stats *stats.Run
+}
+
+// Start starts the pipeline bound to ctx, returning a Run whose statistics can be followed as the documents arrive
+func Start(ctx context.Context, cfg *types.USPTGoConfig) (*Run, error) {
+	return start(ctx, cfg, internal.Dispatcher)
+}
+
+// StartReprocess is Start for the dead letters read from path, which is either a DeadLetter Dir or a file written by a DeadLetter Writer.  The input paths of cfg are ignored; its other options apply as in Start.
+// Each dead letter runs through the pipeline of the bulk file it came from, keeping its original OriginZip.  Documents skipped again are delivered to cfg.DeadLetter, so it should not name path itself.
+func StartReprocess(ctx context.Context, cfg *types.USPTGoConfig, path string) (*Run, error) {
+	return start(ctx, cfg, reprocessDispatcher(path))
+}
+
+type dispatcher func(ctx context.Context, cfg *types.USPTGoConfig) (<-chan *types.USPTGoDoc, <-chan error, *stats.Run, error)
+
+func reprocessDispatcher(path string) dispatcher {
+	return func(ctx context.Context, cfg *types.USPTGoConfig) (<-chan *types.USPTGoDoc, <-chan error, *stats.Run, error) {
+		return internal.ReprocessDispatcher(ctx, cfg, path)
+	}
+}
+
+func start(ctx context.Context, cfg *types.USPTGoConfig, dispatch dispatcher) (*Run, error) {
 
 	// Defaults to a no-op logger which does nothing with log messages
 	if cfg.Logger == nil {
 		cfg.Logger = noOpLogger{}
 	}
 
-	docChan, errChan, err := internal.Dispatcher(cfg)
+	docChan, errChan, runStats, err := dispatch(ctx, cfg)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return docChan, errChan, nil
+	return &Run{Docs: docChan, Errs: errChan, stats: runStats}, nil
+}
+
+// Stats returns the current statistics of the run.  Once both channels are closed they are final, with Done set.
+func (r *Run) Stats() types.Stats {
+	return r.stats.Snapshot()
+}
+
+// Documents runs the pipeline bound to ctx and returns an iterator over its output.  Each step yields either a document with a nil error, or a nil document with an error reported by the pipeline, in the order they arrive.
+// Documents are produced only as fast as the loop consumes them.  Breaking out of the loop cancels the run, and the iterator returns once the pipeline has stopped.  A configuration error is yielded as the only step.
+func Documents(ctx context.Context, cfg *types.USPTGoConfig) iter.Seq2[*types.USPTGoDoc, error] {
+	return documents(ctx, cfg, internal.Dispatcher)
+}
+
+// ReprocessDeadLetters is Documents for the dead letters read from path, as started by StartReprocess
+func ReprocessDeadLetters(ctx context.Context, cfg *types.USPTGoConfig, path string) iter.Seq2[*types.USPTGoDoc, error] {
+	return documents(ctx, cfg, reprocessDispatcher(path))
+}
 
+func documents(ctx context.Context, cfg *types.USPTGoConfig, dispatch dispatcher) iter.Seq2[*types.USPTGoDoc, error] {
+	return func(yield func(*types.USPTGoDoc, error) bool) {
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		run, err := start(ctx, cfg, dispatch)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		docChan, errChan := run.Docs, run.Errs
+
+		// On an early return, stop the run and wait for both channels to close so no pipeline goroutine outlives the loop
+		defer func() {
+			cancel()
+			for range docChan {
+			}
+			for range errChan {
+			}
+		}()
+
+		// Both channels are read together, so a backlog of errors can never block the documents behind it
+		docs, errs := docChan, errChan
+		for docs != nil || errs != nil {
+			select {
+			case doc, ok := <-docs:
+				if !ok {
+					docs = nil
+					continue
+				}
+				if !yield(doc, nil) {
+					return
+				}
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				if !yield(nil, err) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Process runs the pipeline bound to ctx, calling fn with each document in turn.  The pipeline waits on fn, so a slow fn slows the run rather than buffering its output.
+// Returning an error from fn stops the run, and Process returns that error.  Otherwise Process returns the errors reported by the pipeline, such as skipped documents, joined with errors.Join, or nil if there were none.
+func Process(ctx context.Context, cfg *types.USPTGoConfig, fn func(*types.USPTGoDoc) error) error {
+
+	var errs []error
+	for doc, err := range Documents(ctx, cfg) {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := fn(doc); err != nil {
+			return err
+		}
+	}
+	return errors.Join(errs...)
 }
 
 type noOpLogger struct{}
@@ -0,0 +1,1054 @@
+package usptgo
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/diverged/uspt-go/types"
+)
+
+const testGrantTemplate = `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE us-patent-grant SYSTEM "us-patent-grant-v45-2014-04-03.dtd" [ ]>
+<us-patent-grant lang="EN" dtd-version="v4.5 2014-04-03" file="US%[1]d-20180619.XML" status="PRODUCTION" id="us-patent-grant" country="US" date-produced="20180605" date-publ="20180619">
+<us-bibliographic-data-grant>
+<publication-reference><document-id><country>US</country><doc-number>%[1]d</doc-number><kind>B2</kind><date>20180619</date></document-id></publication-reference>
+<invention-title id="d2e43">Widget %[1]d</invention-title>
+</us-bibliographic-data-grant>
+<abstract id="abstract"><p id="p-0001" num="0000">A widget.</p></abstract>
+<description id="description"><p id="p-0002" num="0001">Text.</p></description>
+<claims id="claims"><claim id="CLM-00001" num="00001"><claim-text>1. A widget.</claim-text></claim></claims>
+</us-patent-grant>
+`
+
+// writeTestGrantZip writes a bulk grant zip of n documents, in the layout of the weekly ipg files
+func writeTestGrantZip(t *testing.T, n int) string {
+	t.Helper()
+	return writeTestGrantZipTo(t, t.TempDir(), "ipg180619", n)
+}
+
+// testGrantBulk returns the content of a bulk grant XML file of n documents
+func testGrantBulk(n int) []byte {
+	var sb strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&sb, testGrantTemplate, 10000000+i)
+	}
+	return []byte(sb.String())
+}
+
+// writeTestGrantZipTo writes name.zip into dir, holding name.xml with n documents
+func writeTestGrantZipTo(t *testing.T, dir, name string, n int) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name+".zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	w, err := zw.Create(name + ".xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(testGrantBulk(n)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUSPTGoWithContextCancel(t *testing.T) {
+	path := writeTestGrantZip(t, 2000)
+
+	for _, tuning := range []types.Tuning{{}, {ParserWorkers: 4, TranslatorWorkers: 4, PreserveOrder: true}} {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		docChan, errChan, err := USPTGoWithContext(ctx, &types.USPTGoConfig{InputPath: path, Tuning: tuning})
+		if err != nil {
+			t.Fatalf("USPTGoWithContext returned an error: %v", err)
+		}
+
+		if doc, ok := <-docChan; !ok || doc.Patent.UsBibliographicData.InventionTitle.Text == "" {
+			t.Fatalf("expected a parsed document before cancelling, got %v", doc)
+		}
+		cancel()
+
+		// Abandon docChan; the error channel must still close, reporting the cancellation
+		var sawCanceled bool
+		timeout := time.After(5 * time.Second)
+		for done := false; !done; {
+			select {
+			case err, ok := <-errChan:
+				if !ok {
+					done = true
+					break
+				}
+				if errors.Is(err, context.Canceled) {
+					sawCanceled = true
+				}
+			case <-timeout:
+				t.Fatalf("%+v: errChan was not closed after cancelling", tuning)
+			}
+		}
+		if !sawCanceled {
+			t.Errorf("%+v: expected context.Canceled to be reported on errChan", tuning)
+		}
+
+		// docChan is closed too, once any buffered documents are read
+		for range docChan {
+		}
+	}
+}
+
+func TestUSPTGoWithContextAlreadyCancelled(t *testing.T) {
+	path := writeTestGrantZip(t, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, _, err := USPTGoWithContext(ctx, &types.USPTGoConfig{InputPath: path}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestUSPTGoReadsEveryDocument(t *testing.T) {
+	path := writeTestGrantZip(t, 25)
+
+	docChan, errChan, err := USPTGo(&types.USPTGoConfig{InputPath: path})
+	if err != nil {
+		t.Fatalf("USPTGo returned an error: %v", err)
+	}
+
+	var count int
+	for range docChan {
+		count++
+	}
+	for err := range errChan {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if count != 25 {
+		t.Errorf("expected 25 documents, got %d", count)
+	}
+}
+
+func TestUSPTGoWorkerPools(t *testing.T) {
+	path := writeTestGrantZip(t, 300)
+
+	for _, preserveOrder := range []bool{true, false} {
+		cfg := &types.USPTGoConfig{
+			InputPath: path,
+			Tuning: types.Tuning{
+				ParserWorkers:     8,
+				TranslatorWorkers: 4,
+				BufferSize:        10,
+				PreserveOrder:     preserveOrder,
+			},
+		}
+		docChan, errChan, err := USPTGo(cfg)
+		if err != nil {
+			t.Fatalf("USPTGo returned an error: %v", err)
+		}
+
+		seen := make(map[int]bool)
+		next := 0
+		for doc := range docChan {
+			index := doc.USPTGoMetadata.OriginZip.IndexInZip
+			if preserveOrder && index != next {
+				t.Fatalf("PreserveOrder: expected IndexInZip %d, got %d", next, index)
+			}
+			if want := fmt.Sprintf("Widget %d", 10000000+index); doc.Patent.UsBibliographicData.InventionTitle.Text != want {
+				t.Errorf("document %d has title %q, want %q", index, doc.Patent.UsBibliographicData.InventionTitle.Text, want)
+			}
+			seen[index] = true
+			next++
+		}
+		for err := range errChan {
+			t.Errorf("unexpected error: %v", err)
+		}
+		if len(seen) != 300 {
+			t.Errorf("PreserveOrder %v: expected 300 distinct documents, got %d", preserveOrder, len(seen))
+		}
+	}
+}
+
+func TestUSPTGoManyZips(t *testing.T) {
+	dir := t.TempDir()
+	sizes := map[string]int{"ipg180605": 7, "ipg180612": 11, "ipg180619": 13, "ipg180626": 5}
+	for name, n := range sizes {
+		writeTestGrantZipTo(t, dir, name, n)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a zip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cfg := range []*types.USPTGoConfig{
+		{InputPath: dir},
+		{InputPath: filepath.Join(dir, "ipg*.zip"), Tuning: types.Tuning{MaxConcurrentZips: 3}},
+		{InputPaths: []string{filepath.Join(dir, "ipg180605.zip"), filepath.Join(dir, "ipg1806[12]*.zip"), filepath.Join(dir, "ipg180626.zip")}, Tuning: types.Tuning{MaxConcurrentZips: 4, ParserWorkers: 2}},
+	} {
+		var (
+			mu       sync.Mutex
+			started  = make(map[string]bool)
+			finished = make(map[string]types.ZipEvent)
+		)
+		cfg.OnZipEvent = func(event types.ZipEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			name := strings.TrimSuffix(filepath.Base(event.ZipPath), ".zip")
+			switch event.Kind {
+			case types.ZipStarted:
+				started[name] = true
+			case types.ZipFinished:
+				if !started[name] {
+					t.Errorf("%s finished before it started", name)
+				}
+				finished[name] = event
+			}
+		}
+
+		docChan, errChan, err := USPTGo(cfg)
+		if err != nil {
+			t.Fatalf("USPTGo returned an error: %v", err)
+		}
+
+		counts := make(map[string]int)
+		for doc := range docChan {
+			counts[strings.TrimSuffix(doc.USPTGoMetadata.OriginZip.ZipName, ".zip")]++
+		}
+		for err := range errChan {
+			t.Errorf("unexpected error: %v", err)
+		}
+
+		for name, n := range sizes {
+			if counts[name] != n {
+				t.Errorf("%s: expected %d documents, got %d", name, n, counts[name])
+			}
+			event, ok := finished[name]
+			if !ok {
+				t.Errorf("%s: no finish event", name)
+				continue
+			}
+			if event.Documents != n || event.Total != len(sizes) {
+				t.Errorf("%s: finish event %+v, expected %d documents of %d zips", name, event, n, len(sizes))
+			}
+		}
+	}
+}
+
+func TestUSPTGoNoZipsFound(t *testing.T) {
+	if _, _, err := USPTGo(&types.USPTGoConfig{InputPath: t.TempDir()}); err == nil {
+		t.Error("expected an error for a directory without zip files")
+	}
+}
+
+func TestUSPTGoInputs(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := writeTestGrantZipTo(t, dir, "ipg180605", 3)
+	zipData, err := os.ReadFile(zipPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A tar.gz holding a stray README ahead of the bulk XML file
+	var tarGz bytes.Buffer
+	gw := gzip.NewWriter(&tarGz)
+	tw := tar.NewWriter(gw)
+	for _, file := range []struct {
+		name string
+		data []byte
+	}{{"ipg180612/README.txt", []byte("readme")}, {"ipg180612/ipg180612.xml", testGrantBulk(4)}} {
+		if err := tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write(file.data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ipg180612.tar.gz"), tarGz.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "ipg180619.xml"), testGrantBulk(5), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &types.USPTGoConfig{
+		InputPath: dir,
+		Inputs: []types.Input{
+			{Name: "ipg180626.zip", ReaderAt: bytes.NewReader(zipData), Size: int64(len(zipData))},
+			{Name: "ipg180703.xml", Reader: bytes.NewReader(testGrantBulk(6))},
+			{Name: "ipg180710", Format: types.InputTar, Reader: bytes.NewReader(tarGz.Bytes())},
+		},
+		Tuning: types.Tuning{MaxConcurrentZips: 2},
+	}
+	docChan, errChan, err := USPTGo(cfg)
+	if err != nil {
+		t.Fatalf("USPTGo returned an error: %v", err)
+	}
+
+	counts := make(map[string]int)
+	for doc := range docChan {
+		if doc.Patent.UsBibliographicData.InventionTitle.Text == "" {
+			t.Errorf("%s: document was not parsed", doc.USPTGoMetadata.OriginZip.IndexName)
+		}
+		counts[doc.USPTGoMetadata.OriginZip.ZipName]++
+	}
+	for err := range errChan {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	want := map[string]int{"ipg180605.zip": 3, "ipg180612.tar.gz": 4, "ipg180619.xml": 5, "ipg180626.zip": 3, "ipg180703.xml": 6, "ipg180710": 4}
+	for name, n := range want {
+		if counts[name] != n {
+			t.Errorf("%s: expected %d documents, got %d", name, n, counts[name])
+		}
+	}
+}
+
+func TestDocumentsInterleavesErrors(t *testing.T) {
+	dir := t.TempDir()
+	zipPath := writeTestGrantZipTo(t, dir, "ipg180619", 50)
+
+	// More failing inputs than the output buffers hold, which deadlocks a consumer reading docChan before errChan
+	paths := []string{zipPath}
+	for i := 0; i < 20; i++ {
+		paths = append(paths, filepath.Join(dir, fmt.Sprintf("missing%d.zip", i)))
+	}
+	cfg := &types.USPTGoConfig{InputPaths: paths, Tuning: types.Tuning{OutputBufferSize: 2, MaxConcurrentZips: 4}}
+
+	done := make(chan struct{})
+	var docs, errs int
+	go func() {
+		defer close(done)
+		for doc, err := range Documents(context.Background(), cfg) {
+			if err != nil {
+				errs++
+				continue
+			}
+			if doc == nil {
+				t.Error("expected a document alongside a nil error")
+			}
+			docs++
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Documents did not finish")
+	}
+	if docs != 50 || errs != 20 {
+		t.Errorf("expected 50 documents and 20 errors, got %d and %d", docs, errs)
+	}
+}
+
+func TestDocumentsEarlyStop(t *testing.T) {
+	path := writeTestGrantZip(t, 2000)
+
+	var count int
+	for doc, err := range Documents(context.Background(), &types.USPTGoConfig{InputPath: path}) {
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if doc.Patent.UsBibliographicData.InventionTitle.Text == "" {
+			t.Errorf("document %d was not parsed", count)
+		}
+		if count++; count == 3 {
+			break
+		}
+	}
+	if count != 3 {
+		t.Errorf("expected to stop after 3 documents, got %d", count)
+	}
+}
+
+func TestDocumentsCancelMidStream(t *testing.T) {
+	// Documents which fail to parse keep the parser workers sending to errChan while the run is cancelled
+	var bulk strings.Builder
+	for i := 0; i < 200; i++ {
+		doc := fmt.Sprintf(testGrantTemplate, 10000000+i)
+		if i%2 == 1 {
+			doc = strings.ReplaceAll(doc, "</claim></claims>", "</claims>")
+		}
+		bulk.WriteString(doc)
+	}
+
+	for _, tuning := range []types.Tuning{{}, {ParserWorkers: 4, TranslatorWorkers: 4}, {ParserWorkers: 4, TranslatorWorkers: 4, PreserveOrder: true, BufferSize: 1}} {
+		for i := 0; i < 20; i++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			cfg := &types.USPTGoConfig{Inputs: []types.Input{{Name: "ipg180619.xml", Reader: strings.NewReader(bulk.String())}}, Tuning: tuning}
+
+			var docs int
+			for _, err := range Documents(ctx, cfg) {
+				if err == nil {
+					docs++
+				}
+				if docs == 3 {
+					cancel()
+				}
+			}
+			cancel()
+			if docs < 3 {
+				t.Fatalf("%+v: expected at least 3 documents before cancelling, got %d", tuning, docs)
+			}
+		}
+	}
+}
+
+func TestDocumentsConfigError(t *testing.T) {
+	var steps int
+	for doc, err := range Documents(context.Background(), &types.USPTGoConfig{}) {
+		steps++
+		if doc != nil || err == nil {
+			t.Errorf("expected only an error, got %v, %v", doc, err)
+		}
+	}
+	if steps != 1 {
+		t.Errorf("expected a single step, got %d", steps)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	path := writeTestGrantZip(t, 40)
+
+	var count int
+	err := Process(context.Background(), &types.USPTGoConfig{InputPath: path}, func(doc *types.USPTGoDoc) error {
+		count++
+		return nil
+	})
+	if err != nil || count != 40 {
+		t.Errorf("expected 40 documents and no error, got %d and %v", count, err)
+	}
+
+	errStop := errors.New("stop")
+	count = 0
+	err = Process(context.Background(), &types.USPTGoConfig{InputPath: path}, func(doc *types.USPTGoDoc) error {
+		if count++; count == 5 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || count != 5 {
+		t.Errorf("expected to stop after 5 documents with errStop, got %d and %v", count, err)
+	}
+
+	// Errors reported by the pipeline are returned once the run is complete
+	err = Process(context.Background(), &types.USPTGoConfig{InputPaths: []string{path, filepath.Join(t.TempDir(), "missing.zip")}}, func(doc *types.USPTGoDoc) error {
+		return nil
+	})
+	var usptgoErr *types.USPTGoError
+	if !errors.As(err, &usptgoErr) || !usptgoErr.Skipped {
+		t.Errorf("expected the skipped zip to be reported, got %v", err)
+	}
+}
+
+func TestUSPTGoMixedSchemas(t *testing.T) {
+	// A weekly file spanning a DTD revision, with one document of an unknown DTD
+	v45 := string(testGrantBulk(2))
+	v44 := strings.ReplaceAll(string(testGrantBulk(1)), "us-patent-grant-v45-2014-04-03.dtd", "us-patent-grant-v44-2013-05-16.dtd")
+	unknown := strings.ReplaceAll(string(testGrantBulk(1)), "us-patent-grant-v45-2014-04-03.dtd", "us-patent-grant-v99-2099-01-01.dtd")
+
+	path := filepath.Join(t.TempDir(), "ipg130604.zip")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("ipg130604.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(v44 + v45 + unknown)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	docChan, errChan, err := USPTGo(&types.USPTGoConfig{InputPath: path})
+	if err != nil {
+		t.Fatalf("USPTGo returned an error: %v", err)
+	}
+
+	var schemas []int8
+	for doc := range docChan {
+		origin := doc.USPTGoMetadata.OriginZip
+		if origin.DTD != origin.Schema {
+			t.Errorf("%s: DTD %q differs from Schema %q", origin.IndexName, origin.DTD, origin.Schema)
+		}
+		schemas = append(schemas, origin.SchemaVersion)
+	}
+	var skipped int
+	for err := range errChan {
+		if usptgoErr, ok := err.(*types.USPTGoError); ok && usptgoErr.Skipped && strings.Contains(usptgoErr.Error(), "v99") {
+			skipped++
+			continue
+		}
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if fmt.Sprint(schemas) != "[44 45 45]" || skipped != 1 {
+		t.Errorf("expected schema versions [44 45 45] and 1 skipped document, got %v and %d", schemas, skipped)
+	}
+}
+
+func TestUSPTGoUnknownSchemaFirstEntry(t *testing.T) {
+	// An archive whose first file has an unregistered DTD, ahead of a file the pipeline is chosen on
+	unknown := strings.ReplaceAll(string(testGrantBulk(2)), "us-patent-grant-v45-2014-04-03.dtd", "us-patent-grant-v99-2099-01-01.dtd")
+	files := []struct{ name, data string }{
+		{"ipg990101.xml", unknown},
+		{"ipg180619.xml", string(testGrantBulk(3))},
+	}
+
+	var zipBytes, tarBytes bytes.Buffer
+	zw := zip.NewWriter(&zipBytes)
+	tw := tar.NewWriter(&tarBytes)
+	for _, file := range files {
+		w, err := zw.Create(file.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(file.data)); err != nil {
+			t.Fatal(err)
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: file.name, Mode: 0o644, Size: int64(len(file.data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(file.data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, input := range []types.Input{
+		{Name: "ipg180619.zip", ReaderAt: bytes.NewReader(zipBytes.Bytes()), Size: int64(zipBytes.Len())},
+		{Name: "ipg180619.tar", Reader: bytes.NewReader(tarBytes.Bytes())},
+	} {
+		var docs, unknownSchemas int
+		for _, err := range Documents(context.Background(), &types.USPTGoConfig{Inputs: []types.Input{input}}) {
+			switch {
+			case err == nil:
+				docs++
+			case errors.Is(err, types.ErrUnknownSchema):
+				var usptgoErr *types.USPTGoError
+				if errors.As(err, &usptgoErr) && !strings.HasPrefix(usptgoErr.Name, "ipg990101-") {
+					t.Errorf("%s: unknown schema reported for %s", input.Name, usptgoErr.Name)
+				}
+				unknownSchemas++
+			default:
+				t.Errorf("%s: unexpected error: %v", input.Name, err)
+			}
+		}
+		if docs != 3 || unknownSchemas != 2 {
+			t.Errorf("%s: expected 3 documents and 2 unknown schema errors, got %d and %d", input.Name, docs, unknownSchemas)
+		}
+	}
+}
+
+func TestUSPTGoMixedSchemasCancel(t *testing.T) {
+	// Documents of an unknown schema are reported by the splitter itself, which may still be sending to errChan when the run is cancelled
+	var bulk strings.Builder
+	for i := 0; i < 200; i++ {
+		doc := fmt.Sprintf(testGrantTemplate, 10000000+i)
+		if i%2 == 1 {
+			doc = strings.ReplaceAll(doc, "us-patent-grant-v45-2014-04-03.dtd", "us-patent-grant-v99-2099-01-01.dtd")
+		}
+		bulk.WriteString(doc)
+	}
+
+	for _, tuning := range []types.Tuning{{}, {ParserWorkers: 4, TranslatorWorkers: 4, BufferSize: 1}} {
+		for i := 0; i < 20; i++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			cfg := &types.USPTGoConfig{Inputs: []types.Input{{Name: "ipg130604.xml", Reader: strings.NewReader(bulk.String())}}, Tuning: tuning}
+
+			var docs, unknown int
+			for _, err := range Documents(ctx, cfg) {
+				switch {
+				case errors.Is(err, types.ErrUnknownSchema):
+					unknown++
+				case err == nil:
+					docs++
+				}
+				if docs >= 3 && unknown > 0 {
+					cancel()
+				}
+			}
+			cancel()
+			if docs < 3 || unknown == 0 {
+				t.Fatalf("%+v: expected documents and unknown schema errors before cancelling, got %d and %d", tuning, docs, unknown)
+			}
+		}
+	}
+}
+
+func TestUSPTGoRawSplitDocAndProjection(t *testing.T) {
+	path := writeTestGrantZip(t, 5)
+
+	for _, cfg := range []*types.USPTGoConfig{
+		{InputPath: path},
+		{InputPath: path, ReturnRawSplitDoc: true},
+		{InputPath: path, Projection: types.ProjectBibliographic},
+		{InputPath: path, Projection: types.ProjectClaims, Tuning: types.Tuning{TranslatorWorkers: 2, PreserveOrder: true}},
+	} {
+		var count int
+		err := Process(context.Background(), cfg, func(doc *types.USPTGoDoc) error {
+			count++
+			patent := doc.Patent
+			if hasRaw := doc.RawSplitDoc != nil; hasRaw != cfg.ReturnRawSplitDoc {
+				t.Errorf("ReturnRawSplitDoc %v: RawSplitDoc present = %v", cfg.ReturnRawSplitDoc, hasRaw)
+			}
+			if patent.UsBibliographicData.InventionTitle.Text == "" {
+				t.Errorf("projection %d: missing the title", cfg.Projection)
+			}
+			if hasDescription := patent.Description.Content != ""; hasDescription != cfg.Projection.IncludesDescription() {
+				t.Errorf("projection %d: description present = %v", cfg.Projection, hasDescription)
+			}
+			if hasClaims := len(patent.StructuredClaims) > 0; hasClaims != cfg.Projection.IncludesClaims() {
+				t.Errorf("projection %d: structured claims present = %v", cfg.Projection, hasClaims)
+			}
+			return nil
+		})
+		if err != nil || count != 5 {
+			t.Errorf("expected 5 documents and no error, got %d and %v", count, err)
+		}
+	}
+}
+
+func TestUSPTGoFilter(t *testing.T) {
+	path := writeTestGrantZip(t, 10)
+
+	var finished types.ZipEvent
+	cfg := &types.USPTGoConfig{
+		InputPath: path,
+		Filter: types.Filter{
+			// Drops 10000009
+			Raw: func(raw []byte, metadata types.USPTGoMetadata) bool {
+				return !bytes.Contains(raw, []byte("<doc-number>10000009</doc-number>"))
+			},
+			// Drops the odd numbers, 10000001 to 10000007
+			Header: func(header types.DocHeader) bool {
+				return header.Kind == "B2" && header.Date == "20180619" && (header.DocNumber[len(header.DocNumber)-1]-'0')%2 == 0
+			},
+			// Drops 10000000
+			Patent: func(patent *types.Patent) bool {
+				return patent.UsBibliographicData.InventionTitle.Text != "Widget 10000000"
+			},
+		},
+		Tuning: types.Tuning{ParserWorkers: 3, PreserveOrder: true},
+		OnZipEvent: func(event types.ZipEvent) {
+			if event.Kind == types.ZipFinished {
+				finished = event
+			}
+		},
+	}
+
+	var docNumbers []string
+	err := Process(context.Background(), cfg, func(doc *types.USPTGoDoc) error {
+		docNumbers = append(docNumbers, doc.Patent.UsBibliographicData.PublicationReference.DocumentID.DocNumber)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Process returned an error: %v", err)
+	}
+	if got := strings.Join(docNumbers, ","); got != "10000002,10000004,10000006,10000008" {
+		t.Errorf("kept %s", got)
+	}
+	if finished.Documents != 4 || finished.Prefiltered != 5 || finished.Postfiltered != 1 || finished.Errors != 0 {
+		t.Errorf("unexpected ZipFinished counts: %+v", finished)
+	}
+}
+
+func TestStartStats(t *testing.T) {
+	dir := t.TempDir()
+	writeTestGrantZipTo(t, dir, "ipg180619", 4)
+	unknown := strings.ReplaceAll(string(testGrantBulk(1)), "us-patent-grant-v45-2014-04-03.dtd", "us-patent-grant-v99-2099-01-01.dtd")
+	if err := os.WriteFile(filepath.Join(dir, "ipg180626.xml"), append(testGrantBulk(2), unknown...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mu        sync.Mutex
+		progress  []types.Stats
+		finalCall types.Stats
+	)
+	cfg := &types.USPTGoConfig{
+		InputPath: dir,
+		Filter: types.Filter{
+			Header: func(header types.DocHeader) bool { return header.DocNumber != "10000001" },
+		},
+		Progress: func(stats types.Stats) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, stats)
+			finalCall = stats
+		},
+		ProgressInterval: time.Millisecond,
+	}
+
+	run, err := Start(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("Start returned an error: %v", err)
+	}
+	for range run.Docs {
+		// Polling while the run proceeds must be safe
+		_ = run.Stats()
+	}
+	for range run.Errs {
+	}
+
+	stats := run.Stats()
+	want := types.StageCounts{Split: 6, Prefiltered: 2, Parsed: 4, Translated: 4, Documents: 4, Errors: 1}
+	if stats.StageCounts != want {
+		t.Errorf("got counts %+v, want %+v", stats.StageCounts, want)
+	}
+	if !stats.Done || len(stats.Zips) != 2 || stats.Skipped["detecting the document schema"] != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	for _, zipStats := range stats.Zips {
+		if !zipStats.Finished || zipStats.BytesTotal == 0 || zipStats.BytesRead != zipStats.BytesTotal || zipStats.TranslateTime == 0 || zipStats.TranslateTime > zipStats.Elapsed {
+			t.Errorf("unexpected zip stats: %+v", zipStats)
+		}
+	}
+	if stats.BytesRead != stats.Zips[0].BytesRead+stats.Zips[1].BytesRead || stats.DocumentsPerSecond == 0 {
+		t.Errorf("unexpected totals: %+v", stats)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) == 0 || !finalCall.Done || finalCall.StageCounts != want {
+		t.Errorf("expected a final Progress call with the final counts, got %d calls ending with %+v", len(progress), finalCall)
+	}
+}
+
+func TestUSPTGoErrorDetails(t *testing.T) {
+	good := fmt.Sprintf(testGrantTemplate, 10000000)
+	unknown := strings.ReplaceAll(fmt.Sprintf(testGrantTemplate, 10000001), "us-patent-grant-v45-2014-04-03.dtd", "us-patent-grant-v99-2099-01-01.dtd")
+	broken := strings.ReplaceAll(fmt.Sprintf(testGrantTemplate, 10000002), "</claim></claims>", "</claims>")
+	bulk := good + unknown + broken
+
+	docChan, errChan, err := USPTGo(&types.USPTGoConfig{Inputs: []types.Input{{Name: "ipg180619.xml", Reader: strings.NewReader(bulk)}}})
+	if err != nil {
+		t.Fatalf("USPTGo returned an error: %v", err)
+	}
+	for range docChan {
+	}
+
+	codes := make(map[string]*types.USPTGoError)
+	for err := range errChan {
+		var usptgoErr *types.USPTGoError
+		if !errors.As(err, &usptgoErr) {
+			t.Fatalf("unexpected error type: %v", err)
+		}
+		codes[usptgoErr.Code()] = usptgoErr
+	}
+
+	unknownErr := codes["unknown_schema"]
+	if unknownErr == nil || !errors.Is(unknownErr, types.ErrUnknownSchema) || unknownErr.ByteOffset != int64(len(good)) || unknownErr.ZipInfo.ZipName != "ipg180619.xml" {
+		t.Errorf("unexpected unknown schema error: %+v", unknownErr)
+	}
+	brokenErr := codes["unmarshal"]
+	if brokenErr == nil || !errors.Is(brokenErr, types.ErrUnmarshal) || brokenErr.DocID != "US10000002B2" ||
+		brokenErr.ByteOffset != int64(len(good+unknown)) || brokenErr.ZipInfo.IndexInZip != 2 || brokenErr.ZipInfo.Schema == "" {
+		t.Errorf("unexpected unmarshal error: %+v", brokenErr)
+	}
+	if len(codes) != 2 {
+		t.Errorf("expected exactly the two errors, got %v", codes)
+	}
+}
+
+func TestDeadLetters(t *testing.T) {
+	good := fmt.Sprintf(testGrantTemplate, 10000000)
+	unknown := strings.ReplaceAll(fmt.Sprintf(testGrantTemplate, 10000001), "us-patent-grant-v45-2014-04-03.dtd", "us-patent-grant-v99-2099-01-01.dtd")
+	broken := strings.ReplaceAll(fmt.Sprintf(testGrantTemplate, 10000002), "</claim></claims>", "</claims>")
+	bulk := good + unknown + broken
+
+	dir := filepath.Join(t.TempDir(), "dead")
+	var lines bytes.Buffer
+	cfg := &types.USPTGoConfig{
+		Inputs:     []types.Input{{Name: "ipg180619.xml", Reader: strings.NewReader(bulk)}},
+		DeadLetter: types.DeadLetter{Dir: dir, Writer: &lines},
+	}
+	for _, err := range Documents(context.Background(), cfg) {
+		var usptgoErr *types.USPTGoError
+		if errors.As(err, &usptgoErr) && usptgoErr.RawSplitDoc != nil {
+			t.Errorf("RawSplitDoc returned without ReturnRawSplitDoc: %v", err)
+		}
+	}
+
+	// Both skipped documents are written to the Dir, beside their records
+	sidecars, err := filepath.Glob(filepath.Join(dir, "ipg180619.xml-*", "*.json"))
+	if err != nil || len(sidecars) != 2 {
+		t.Fatalf("expected 2 dead letter records, got %v (%v)", sidecars, err)
+	}
+	for _, sidecar := range sidecars {
+		raw, err := os.ReadFile(strings.TrimSuffix(sidecar, ".json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if trimmed := strings.TrimSpace(string(raw)); trimmed != strings.TrimSpace(unknown) && trimmed != strings.TrimSpace(broken) {
+			t.Errorf("unexpected raw document in %s: %q", sidecar, raw)
+		}
+	}
+
+	// And to the Writer, one line each
+	linesPath := filepath.Join(t.TempDir(), "dead.jsonl")
+	if err := os.WriteFile(linesPath, lines.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	codes := make(map[string]bool)
+	for doc, err := range ReprocessDeadLetters(context.Background(), &types.USPTGoConfig{}, linesPath) {
+		if doc != nil {
+			t.Errorf("unexpected document from an unchanged dead letter: %s", doc.USPTGoMetadata.OriginZip.IndexName)
+			continue
+		}
+		var usptgoErr *types.USPTGoError
+		if errors.As(err, &usptgoErr) {
+			codes[usptgoErr.Code()] = true
+		}
+	}
+	if len(codes) != 2 || !codes["unknown_schema"] || !codes["unmarshal"] {
+		t.Errorf("expected the unknown_schema and unmarshal errors again, got %v", codes)
+	}
+
+	// Correct the raw documents by hand, then reprocess the Dir
+	for _, sidecar := range sidecars {
+		rawPath := strings.TrimSuffix(sidecar, ".json")
+		raw, _ := os.ReadFile(rawPath)
+		fixed := strings.ReplaceAll(string(raw), "us-patent-grant-v99-2099-01-01.dtd", "us-patent-grant-v45-2014-04-03.dtd")
+		fixed = strings.ReplaceAll(fixed, "</claim-text></claims>", "</claim-text></claim></claims>")
+		if err := os.WriteFile(rawPath, []byte(fixed), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run, err := StartReprocess(context.Background(), &types.USPTGoConfig{}, dir)
+	if err != nil {
+		t.Fatalf("StartReprocess returned an error: %v", err)
+	}
+	offsets := make(map[int]int64)
+	for doc := range run.Docs {
+		origin := doc.USPTGoMetadata.OriginZip
+		if origin.ZipName != "ipg180619.xml" || origin.SchemaVersion != 45 {
+			t.Errorf("unexpected origin of a reprocessed document: %+v", origin)
+		}
+		offsets[origin.IndexInZip] = origin.ByteOffset
+	}
+	for err := range run.Errs {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(offsets) != 2 || offsets[1] != int64(len(good)) || offsets[2] != int64(len(good+unknown)) {
+		t.Errorf("expected the documents at index 1 and 2 with their original offsets, got %v", offsets)
+	}
+	if stats := run.Stats(); stats.Documents != 2 || len(stats.Zips) != 1 {
+		t.Errorf("unexpected stats of the reprocessing run: %+v", stats)
+	}
+}
+
+func TestDeadLetterNameCollisions(t *testing.T) {
+	broken := func(number int) string {
+		return strings.ReplaceAll(fmt.Sprintf(testGrantTemplate, number), "</claim></claims>", "</claims>")
+	}
+
+	// A tar holding entries of the same name in two directories
+	var tarBytes bytes.Buffer
+	tw := tar.NewWriter(&tarBytes)
+	for i, name := range []string{"a/ipg180619.xml", "b/ipg180619.xml"} {
+		data := broken(10000010 + i)
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "dead")
+	cfg := &types.USPTGoConfig{
+		Inputs: []types.Input{
+			// Inputs of the same name from two directories
+			{Name: "2018/ipg180619.xml", Reader: strings.NewReader(broken(10000000))},
+			{Name: "2019/ipg180619.xml", Reader: strings.NewReader(broken(10000001))},
+			{Name: "ipg180619.tar", Reader: bytes.NewReader(tarBytes.Bytes())},
+		},
+		DeadLetter: types.DeadLetter{Dir: dir},
+	}
+	var skipped int
+	for _, err := range Documents(context.Background(), cfg) {
+		if err != nil {
+			skipped++
+		}
+	}
+
+	var raws []string
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || strings.HasSuffix(path, ".json") {
+			return err
+		}
+		raw, err := os.ReadFile(path)
+		raws = append(raws, strings.TrimSpace(string(raw)))
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if skipped != 4 || len(raws) != 4 {
+		t.Fatalf("expected 4 skipped documents in 4 dead letters, got %d in %d", skipped, len(raws))
+	}
+	for _, number := range []int{10000000, 10000001, 10000010, 10000011} {
+		var found bool
+		for _, raw := range raws {
+			found = found || raw == strings.TrimSpace(broken(number))
+		}
+		if !found {
+			t.Errorf("the dead letter of %d was overwritten", number)
+		}
+	}
+}
+
+func TestReprocessDeadLettersSameName(t *testing.T) {
+	broken := func(number int) string {
+		return strings.ReplaceAll(fmt.Sprintf(testGrantTemplate, number), "</claim></claims>", "</claims>")
+	}
+
+	// Zips of the same name in two directories, each with two skipped documents
+	root := t.TempDir()
+	var paths []string
+	for i, year := range []string{"2018", "2019"} {
+		if err := os.Mkdir(filepath.Join(root, year), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(root, year, "ipg180619.zip")
+		f, err := os.Create(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw := zip.NewWriter(f)
+		w, err := zw.Create("ipg180619.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(broken(10000000+10*i) + broken(10000001+10*i))); err != nil {
+			t.Fatal(err)
+		}
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+		paths = append(paths, path)
+	}
+
+	dir := filepath.Join(t.TempDir(), "dead")
+	for range Documents(context.Background(), &types.USPTGoConfig{InputPaths: paths, DeadLetter: types.DeadLetter{Dir: dir}}) {
+	}
+
+	// Each zip is reprocessed as an input of its own, with its documents in order
+	var mu sync.Mutex
+	errorsByZip := make(map[string]int)
+	cfg := &types.USPTGoConfig{OnZipEvent: func(event types.ZipEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		if event.Kind == types.ZipFinished {
+			errorsByZip[event.ZipPath] += event.Errors
+		}
+	}}
+	numbers := make(map[string][]string)
+	for _, err := range ReprocessDeadLetters(context.Background(), cfg, dir) {
+		var usptgoErr *types.USPTGoError
+		if errors.As(err, &usptgoErr) {
+			numbers[usptgoErr.ZipInfo.ZipPath] = append(numbers[usptgoErr.ZipInfo.ZipPath], usptgoErr.DocID)
+		}
+	}
+	if len(errorsByZip) != 2 {
+		t.Errorf("expected the dead letters of 2 zips reprocessed apart, got %v", errorsByZip)
+	}
+	if got := fmt.Sprint(numbers[paths[0]], numbers[paths[1]]); got != "[US10000000B2 US10000001B2] [US10000010B2 US10000011B2]" {
+		t.Errorf("unexpected dead letters reprocessed for each zip: %s", got)
+	}
+}
+
+func TestUSPTGoLenient(t *testing.T) {
+	good := fmt.Sprintf(testGrantTemplate, 10000000)
+	brokenClaims := strings.ReplaceAll(fmt.Sprintf(testGrantTemplate, 10000001), "</claim></claims>", "</claims>")
+	brokenDescription := strings.ReplaceAll(fmt.Sprintf(testGrantTemplate, 10000002), "Text.</p></description>", "Text.</description>")
+	bulk := good + brokenClaims + brokenDescription
+
+	for _, lenient := range []bool{false, true} {
+		run, err := Start(context.Background(), &types.USPTGoConfig{
+			Inputs:  []types.Input{{Name: "ipg180619.xml", Reader: strings.NewReader(bulk)}},
+			Lenient: lenient,
+		})
+		if err != nil {
+			t.Fatalf("Start returned an error: %v", err)
+		}
+
+		docs := make(map[string]*types.USPTGoDoc)
+		for doc := range run.Docs {
+			docs[doc.Patent.Normalized.PublicationNumber] = doc
+		}
+		for err := range run.Errs {
+			if lenient {
+				t.Errorf("unexpected error in lenient mode: %v", err)
+			}
+		}
+		stats := run.Stats()
+
+		if !lenient {
+			if len(docs) != 1 || stats.Errors != 2 || stats.Partial != 0 {
+				t.Errorf("strict: expected 1 document and 2 errors, got %d and %d", len(docs), stats.Errors)
+			}
+			continue
+		}
+
+		if len(docs) != 3 || stats.Partial != 2 {
+			t.Fatalf("lenient: expected 3 documents, 2 of them partial, got %d and %d", len(docs), stats.Partial)
+		}
+		if diagnostics := docs["US10000000B2"].Diagnostics; len(diagnostics) != 0 {
+			t.Errorf("unexpected diagnostics of the intact document: %v", diagnostics)
+		}
+
+		claimsDoc := docs["US10000001B2"]
+		if diagnostics := claimsDoc.Diagnostics; len(diagnostics) != 1 || diagnostics[0].Section != types.SectionClaims {
+			t.Errorf("expected a claims diagnostic, got %v", diagnostics)
+		}
+		if claimsDoc.Patent.Claims.Content != "" || claimsDoc.Patent.Description.Content == "" {
+			t.Errorf("expected the description without the claims, got %+v", claimsDoc.Patent.Description)
+		}
+
+		descriptionDoc := docs["US10000002B2"]
+		if diagnostics := descriptionDoc.Diagnostics; len(diagnostics) != 1 || diagnostics[0].Section != types.SectionDescription {
+			t.Errorf("expected a description diagnostic, got %v", diagnostics)
+		}
+		if descriptionDoc.Patent.Description.Content != "" || len(descriptionDoc.Patent.StructuredClaims) != 1 {
+			t.Errorf("expected the claims without the description, got %d structured claims", len(descriptionDoc.Patent.StructuredClaims))
+		}
+	}
+}